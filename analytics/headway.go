@@ -0,0 +1,246 @@
+package analytics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Setheck/oba"
+)
+
+const (
+	defaultSpeed         = 5.0
+	defaultBunchingRatio = 0.25
+	defaultGapRatio      = 2.0
+)
+
+// HeadwayOptions - how headways are estimated and judged, zero values use the
+// defaults
+type HeadwayOptions struct {
+	// Speed - meters per second used to turn the distance between two
+	// vehicles into time (default 5)
+	Speed float64
+	// BunchingRatio - a headway below this fraction of the scheduled headway
+	// is bunching (default 0.25)
+	BunchingRatio float64
+	// GapRatio - a headway above this multiple of the scheduled headway is a
+	// gap (default 2)
+	GapRatio float64
+	// ScheduledHeadway - the scheduled headway of a route and direction, see
+	// ScheduledHeadways. When nil or zero the headway of the trip frequency is
+	// used, and without either no events are raised for the route.
+	ScheduledHeadway func(routeID, directionID string) time.Duration
+	// OnEvent - called once when a pair of vehicles starts bunching or
+	// starts a gap
+	OnEvent func(HeadwayEvent)
+}
+
+func (o HeadwayOptions) withDefaults() HeadwayOptions {
+	if o.Speed <= 0 {
+		o.Speed = defaultSpeed
+	}
+	if o.BunchingRatio <= 0 {
+		o.BunchingRatio = defaultBunchingRatio
+	}
+	if o.GapRatio <= 0 {
+		o.GapRatio = defaultGapRatio
+	}
+	return o
+}
+
+// Headway - the spacing between a vehicle and the one ahead of it on the same
+// route and direction
+type Headway struct {
+	RouteID     string
+	DirectionID string
+	Leader      string
+	Follower    string
+	// Distance - meters along the trip between the vehicles
+	Distance  float64
+	Observed  time.Duration
+	Scheduled time.Duration
+}
+
+// Ratio - observed over scheduled headway, zero when there is no schedule
+func (h Headway) Ratio() float64 {
+	if h.Scheduled <= 0 {
+		return 0
+	}
+	return float64(h.Observed) / float64(h.Scheduled)
+}
+
+// EventKind - the kind of a headway event
+type EventKind string
+
+const (
+	Bunching EventKind = "bunching"
+	Gap      EventKind = "gap"
+)
+
+// HeadwayEvent - a headway outside the bunching or gap ratio
+type HeadwayEvent struct {
+	Kind    EventKind
+	Time    time.Time
+	Headway Headway
+}
+
+// RouteHeadways - the headways of one route and direction, leading vehicle
+// first
+type RouteHeadways struct {
+	RouteID     string
+	DirectionID string
+	Headways    []Headway
+}
+
+// HeadwaySnapshot - the result of analyzing one poll of vehicles
+type HeadwaySnapshot struct {
+	Time   time.Time
+	Routes []RouteHeadways
+	Events []HeadwayEvent
+}
+
+// HeadwayAnalyzer - detects bunching and gaps between vehicles. It remembers
+// the events of the previous poll so OnEvent fires once per event. It is safe
+// for concurrent use.
+type HeadwayAnalyzer struct {
+	opts   HeadwayOptions
+	mu     sync.Mutex
+	active map[pair]EventKind
+}
+
+type pair struct {
+	leader, follower string
+}
+
+// NewHeadwayAnalyzer - an analyzer using opts
+func NewHeadwayAnalyzer(opts HeadwayOptions) *HeadwayAnalyzer {
+	return &HeadwayAnalyzer{
+		opts:   opts.withDefaults(),
+		active: make(map[pair]EventKind),
+	}
+}
+
+type routeDirection struct {
+	route, direction string
+}
+
+// Analyze - the headways and events of a VehiclesForAgency result at time at.
+// Only vehicles in progress on a trip are considered; vehicles are ordered by
+// DistanceAlongTrip within their route and direction.
+func (a *HeadwayAnalyzer) Analyze(vs []oba.VehicleStatus, at time.Time) HeadwaySnapshot {
+	groups := make(map[routeDirection][]oba.VehicleStatus)
+	for _, v := range vs {
+		if v.Trip.RouteID == "" || (v.TripStatus.Phase != "" && v.TripStatus.Phase != "in_progress") {
+			continue
+		}
+		k := routeDirection{route: v.Trip.RouteID, direction: v.Trip.DirectionID}
+		groups[k] = append(groups[k], v)
+	}
+
+	snap := HeadwaySnapshot{Time: at}
+	for k, g := range groups {
+		sort.Slice(g, func(i, j int) bool {
+			return g[i].TripStatus.DistanceAlongTrip > g[j].TripStatus.DistanceAlongTrip
+		})
+		rh := RouteHeadways{RouteID: k.route, DirectionID: k.direction}
+		for i := 1; i < len(g); i++ {
+			h := a.headway(k, g[i-1], g[i])
+			rh.Headways = append(rh.Headways, h)
+			if kind, ok := a.classify(h); ok {
+				snap.Events = append(snap.Events, HeadwayEvent{Kind: kind, Time: at, Headway: h})
+			}
+		}
+		if len(rh.Headways) > 0 {
+			snap.Routes = append(snap.Routes, rh)
+		}
+	}
+	sort.Slice(snap.Routes, func(i, j int) bool {
+		if snap.Routes[i].RouteID != snap.Routes[j].RouteID {
+			return snap.Routes[i].RouteID < snap.Routes[j].RouteID
+		}
+		return snap.Routes[i].DirectionID < snap.Routes[j].DirectionID
+	})
+
+	a.notify(snap.Events)
+	return snap
+}
+
+func (a *HeadwayAnalyzer) headway(k routeDirection, leader, follower oba.VehicleStatus) Headway {
+	d := leader.TripStatus.DistanceAlongTrip - follower.TripStatus.DistanceAlongTrip
+	h := Headway{
+		RouteID:     k.route,
+		DirectionID: k.direction,
+		Leader:      leader.VehicleID,
+		Follower:    follower.VehicleID,
+		Distance:    d,
+		Observed:    time.Duration(d / a.opts.Speed * float64(time.Second)),
+	}
+	if a.opts.ScheduledHeadway != nil {
+		h.Scheduled = a.opts.ScheduledHeadway(k.route, k.direction)
+	}
+	if h.Scheduled <= 0 && follower.TripStatus.Frequency != nil {
+		h.Scheduled = follower.TripStatus.Frequency.HeadwayDuration()
+	}
+	return h
+}
+
+func (a *HeadwayAnalyzer) classify(h Headway) (EventKind, bool) {
+	r := h.Ratio()
+	switch {
+	case h.Scheduled <= 0:
+		return "", false
+	case r < a.opts.BunchingRatio:
+		return Bunching, true
+	case r > a.opts.GapRatio:
+		return Gap, true
+	}
+	return "", false
+}
+
+// notify - call OnEvent for events that were not active in the previous poll,
+// outside the lock so the callback may use the analyzer
+func (a *HeadwayAnalyzer) notify(events []HeadwayEvent) {
+	a.mu.Lock()
+	active := make(map[pair]EventKind, len(events))
+	var fresh []HeadwayEvent
+	for _, e := range events {
+		p := pair{leader: e.Headway.Leader, follower: e.Headway.Follower}
+		active[p] = e.Kind
+		if a.active[p] != e.Kind {
+			fresh = append(fresh, e)
+		}
+	}
+	a.active = active
+	a.mu.Unlock()
+
+	if a.opts.OnEvent != nil {
+		for _, e := range fresh {
+			a.opts.OnEvent(e)
+		}
+	}
+}
+
+// ScheduledHeadways - the mean scheduled headway of each direction of a route
+// around time at, from the departures at the first stop of each trip within an
+// hour either side. Directions with fewer than two departures are left out.
+func ScheduledHeadways(rs oba.RouteSchedule, at time.Time) map[string]time.Duration {
+	hs := make(map[string]time.Duration)
+	for _, g := range rs.StopTripGroupings {
+		var deps []time.Time
+		for _, t := range g.TripsWithStopTimes {
+			if len(t.StopTimes) == 0 {
+				continue
+			}
+			dep := oba.MillisToTime(t.StopTimes[0].DepartureTime)
+			if d := dep.Sub(at); d >= -time.Hour && d <= time.Hour {
+				deps = append(deps, dep)
+			}
+		}
+		if len(deps) < 2 {
+			continue
+		}
+		sort.Slice(deps, func(i, j int) bool { return deps[i].Before(deps[j]) })
+		hs[g.DirectionID] = deps[len(deps)-1].Sub(deps[0]) / time.Duration(len(deps)-1)
+	}
+	return hs
+}
@@ -0,0 +1,132 @@
+package analytics_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/Setheck/oba/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func vehicle(id, route, direction string, distance float64) oba.VehicleStatus {
+	return oba.VehicleStatus{
+		VehicleID: id,
+		Trip:      oba.Trip{ID: id + "_trip", RouteID: route, DirectionID: direction},
+		TripStatus: oba.TripStatus{
+			DistanceAlongTrip: distance,
+			Phase:             "in_progress",
+		},
+	}
+}
+
+func tenMinutes(routeID, directionID string) time.Duration {
+	return 10 * time.Minute
+}
+
+func TestHeadwayAnalyzer_Analyze(t *testing.T) {
+	var events []analytics.HeadwayEvent
+	a := analytics.NewHeadwayAnalyzer(analytics.HeadwayOptions{
+		Speed:            5,
+		ScheduledHeadway: tenMinutes,
+		OnEvent:          func(e analytics.HeadwayEvent) { events = append(events, e) },
+	})
+
+	// at 5m/s a 10 minute headway is 3000m
+	vs := []oba.VehicleStatus{
+		vehicle("v1", "R", "0", 12000),
+		vehicle("v2", "R", "0", 9000),
+		vehicle("v3", "R", "0", 8700),
+		vehicle("v4", "R", "0", 0),
+		vehicle("v5", "R", "1", 100),
+		vehicle("v6", "S", "0", 100),
+	}
+	layover := vehicle("v7", "R", "0", 50)
+	layover.TripStatus.Phase = "layover_during"
+	vs = append(vs, layover)
+
+	snap := a.Analyze(vs, TestTime)
+	if assert.Len(t, snap.Routes, 1, "routes with more than one vehicle") {
+		r := snap.Routes[0]
+		assert.Equal(t, "R", r.RouteID)
+		if assert.Len(t, r.Headways, 3) {
+			assert.Equal(t, "v1", r.Headways[0].Leader)
+			assert.Equal(t, "v2", r.Headways[0].Follower)
+			assert.Equal(t, 10*time.Minute, r.Headways[0].Observed)
+			assert.InDelta(t, 1.0, r.Headways[0].Ratio(), 0.001)
+		}
+	}
+	if assert.Len(t, snap.Events, 2) {
+		assert.Equal(t, analytics.Bunching, snap.Events[0].Kind)
+		assert.Equal(t, "v3", snap.Events[0].Headway.Follower)
+		assert.Equal(t, analytics.Gap, snap.Events[1].Kind)
+		assert.Equal(t, "v4", snap.Events[1].Headway.Follower)
+	}
+	assert.Len(t, events, 2, "OnEvent")
+
+	a.Analyze(vs, TestTime.Add(time.Minute))
+	assert.Len(t, events, 2, "ongoing events notified once")
+
+	vs[2].TripStatus.DistanceAlongTrip = 7500
+	a.Analyze(vs, TestTime.Add(2*time.Minute))
+	vs[2].TripStatus.DistanceAlongTrip = 8700
+	a.Analyze(vs, TestTime.Add(4*time.Minute))
+	if assert.Len(t, events, 3, "bunching again after clearing") {
+		assert.Equal(t, analytics.Bunching, events[2].Kind)
+	}
+}
+
+func TestHeadwayAnalyzer_Frequency(t *testing.T) {
+	a := analytics.NewHeadwayAnalyzer(analytics.HeadwayOptions{})
+	v1, v2 := vehicle("v1", "R", "0", 1000), vehicle("v2", "R", "0", 900)
+	assert.Empty(t, a.Analyze([]oba.VehicleStatus{v1, v2}, TestTime).Events, "no schedule")
+
+	v2.TripStatus.Frequency = &oba.Frequency{Headway: 600}
+	snap := a.Analyze([]oba.VehicleStatus{v1, v2}, TestTime)
+	if assert.Len(t, snap.Events, 1) {
+		assert.Equal(t, analytics.Bunching, snap.Events[0].Kind)
+		assert.Equal(t, 10*time.Minute, snap.Events[0].Headway.Scheduled)
+	}
+}
+
+func TestScheduledHeadways(t *testing.T) {
+	ms := func(t time.Time) int { return int(t.UnixNano() / int64(time.Millisecond)) }
+	trip := func(dep time.Time) oba.TripWithStopTimes {
+		return oba.TripWithStopTimes{StopTimes: []oba.ScheduleStopTime{{DepartureTime: ms(dep)}}}
+	}
+	rs := oba.RouteSchedule{StopTripGroupings: []oba.StopTripGrouping{
+		{DirectionID: "0", TripsWithStopTimes: []oba.TripWithStopTimes{
+			trip(TestTime), trip(TestTime.Add(15 * time.Minute)), trip(TestTime.Add(30 * time.Minute)),
+			trip(TestTime.Add(3 * time.Hour)),
+		}},
+		{DirectionID: "1", TripsWithStopTimes: []oba.TripWithStopTimes{trip(TestTime)}},
+	}}
+	hs := analytics.ScheduledHeadways(rs, TestTime)
+	assert.Equal(t, map[string]time.Duration{"0": 15 * time.Minute}, hs)
+}
+
+func TestHeadwayAnalyzer_OnEventReentrant(t *testing.T) {
+	var a *analytics.HeadwayAnalyzer
+	vs := []oba.VehicleStatus{vehicle("v1", "R", "0", 3000), vehicle("v2", "R", "0", 2900)}
+	calls := 0
+	a = analytics.NewHeadwayAnalyzer(analytics.HeadwayOptions{
+		Speed:            5,
+		ScheduledHeadway: tenMinutes,
+		OnEvent: func(e analytics.HeadwayEvent) {
+			calls++
+			a.Analyze(vs, TestTime)
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		a.Analyze(vs, TestTime)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("OnEvent calling back into the analyzer deadlocked")
+	}
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,272 @@
+// Package analytics - service quality measures computed from polled One Bus
+// Away real time data
+package analytics
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Setheck/oba"
+)
+
+// Default thresholds, a vehicle more than a minute early or five minutes late
+// is not on time
+const (
+	DefaultEarly = time.Minute
+	DefaultLate  = 5 * time.Minute
+)
+
+// Thresholds - how far from schedule a vehicle may be and still be on time,
+// zero values use the defaults
+type Thresholds struct {
+	Early time.Duration
+	Late  time.Duration
+}
+
+func (t Thresholds) withDefaults() Thresholds {
+	if t.Early <= 0 {
+		t.Early = DefaultEarly
+	}
+	if t.Late <= 0 {
+		t.Late = DefaultLate
+	}
+	return t
+}
+
+// Bucket - schedule adherence of a sample
+type Bucket string
+
+const (
+	Early  Bucket = "early"
+	OnTime Bucket = "on_time"
+	Late   Bucket = "late"
+)
+
+// Classify - the bucket of a deviation, positive deviations are late
+func (t Thresholds) Classify(deviation time.Duration) Bucket {
+	t = t.withDefaults()
+	switch {
+	case deviation < -t.Early:
+		return Early
+	case deviation > t.Late:
+		return Late
+	default:
+		return OnTime
+	}
+}
+
+// Sample - the schedule deviation of one vehicle at one time
+type Sample struct {
+	RouteID   string
+	TripID    string
+	StopID    string
+	VehicleID string
+	Time      time.Time
+	Deviation time.Duration
+}
+
+// FromTripStatus - a sample from the status of a trip on routeID, false when
+// the status has no real time prediction
+func FromTripStatus(routeID string, ts oba.TripStatus) (Sample, bool) {
+	if ts.ActiveTripID == "" || (ts.Predicted != nil && !*ts.Predicted) {
+		return Sample{}, false
+	}
+	return Sample{
+		RouteID:   routeID,
+		TripID:    ts.ActiveTripID,
+		StopID:    ts.ClosestStop.ID,
+		VehicleID: ts.VehicleID,
+		Time:      oba.MillisToTime(ts.LastUpdateTime),
+		Deviation: time.Duration(ts.ScheduleDeviation) * time.Second,
+	}, true
+}
+
+// FromVehicles - the samples of every vehicle with a predicted trip
+func FromVehicles(vs []oba.VehicleStatus) []Sample {
+	var ss []Sample
+	for _, v := range vs {
+		if s, ok := FromTripStatus(v.Trip.RouteID, v.TripStatus); ok {
+			if s.VehicleID == "" {
+				s.VehicleID = v.VehicleID
+			}
+			ss = append(ss, s)
+		}
+	}
+	return ss
+}
+
+// Dimension - what a report is grouped by
+type Dimension string
+
+const (
+	ByRoute Dimension = "route"
+	ByStop  Dimension = "stop"
+	ByHour  Dimension = "hour"
+)
+
+// OnTimePerformance - aggregates samples into early, on time and late counts.
+// Repeated polls of a vehicle that has not reported since the last poll are
+// counted once. With a window only the samples of the latest window are kept.
+// It is safe for concurrent use.
+type OnTimePerformance struct {
+	thresholds Thresholds
+	loc        *time.Location
+	window     time.Duration
+	latest     time.Time
+	mu         sync.Mutex
+	seen       map[sampleKey]bool
+	samples    []Sample
+}
+
+type sampleKey struct {
+	vehicle, trip string
+	time          time.Time
+}
+
+// NewOnTimePerformance - an empty aggregate classifying with t, hours of day
+// are taken in loc (default local time)
+func NewOnTimePerformance(t Thresholds, loc *time.Location) *OnTimePerformance {
+	if loc == nil {
+		loc = time.Local
+	}
+	return &OnTimePerformance{
+		thresholds: t.withDefaults(),
+		loc:        loc,
+		seen:       make(map[sampleKey]bool),
+	}
+}
+
+// Add - add samples to the aggregate
+func (p *OnTimePerformance) Add(samples ...Sample) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range samples {
+		k := sampleKey{vehicle: s.VehicleID, trip: s.TripID, time: s.Time}
+		if p.seen[k] {
+			continue
+		}
+		p.seen[k] = true
+		p.samples = append(p.samples, s)
+		if s.Time.After(p.latest) {
+			p.latest = s.Time
+		}
+	}
+	if p.window > 0 {
+		p.prune(p.latest.Add(-p.window))
+	}
+}
+
+// SetWindow - keep only the samples within d of the latest one, zero keeps
+// every sample
+func (p *OnTimePerformance) SetWindow(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.window = d
+	if d > 0 {
+		p.prune(p.latest.Add(-d))
+	}
+}
+
+// Prune - drop the samples taken before t, returning how many were dropped
+func (p *OnTimePerformance) Prune(t time.Time) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.prune(t)
+}
+
+func (p *OnTimePerformance) prune(t time.Time) int {
+	kept := p.samples[:0]
+	for _, s := range p.samples {
+		if s.Time.Before(t) {
+			delete(p.seen, sampleKey{vehicle: s.VehicleID, trip: s.TripID, time: s.Time})
+			continue
+		}
+		kept = append(kept, s)
+	}
+	dropped := len(p.samples) - len(kept)
+	p.samples = kept
+	return dropped
+}
+
+// AddVehicles - add the samples of a VehiclesForAgency result
+func (p *OnTimePerformance) AddVehicles(vs []oba.VehicleStatus) {
+	p.Add(FromVehicles(vs)...)
+}
+
+// AddTripStatus - add the sample of a trip status on routeID
+func (p *OnTimePerformance) AddTripStatus(routeID string, ts oba.TripStatus) {
+	if s, ok := FromTripStatus(routeID, ts); ok {
+		p.Add(s)
+	}
+}
+
+// AddTripDetails - add the samples of trip details with a status, as returned
+// by TripsForRoute, TripsForLocation or TripDetails
+func (p *OnTimePerformance) AddTripDetails(tds []oba.TripDetails) {
+	for _, td := range tds {
+		if td.TripStatus != nil {
+			p.AddTripStatus(td.Trip.RouteID, *td.TripStatus)
+		}
+	}
+}
+
+// Len - number of samples in the aggregate
+func (p *OnTimePerformance) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.samples)
+}
+
+// Report - the aggregate grouped by d, rows ordered by key
+func (p *OnTimePerformance) Report(d Dimension) Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rows := make(map[string]*Row)
+	total := make(map[string]time.Duration)
+	for _, s := range p.samples {
+		key := p.key(d, s)
+		r, ok := rows[key]
+		if !ok {
+			r = &Row{Key: key}
+			rows[key] = r
+		}
+		r.Samples++
+		switch p.thresholds.Classify(s.Deviation) {
+		case Early:
+			r.Early++
+		case Late:
+			r.Late++
+		default:
+			r.OnTime++
+		}
+		total[key] += s.Deviation
+	}
+
+	rep := Report{Dimension: d, Thresholds: p.thresholds}
+	for key, r := range rows {
+		r.MeanDeviation = total[key] / time.Duration(r.Samples)
+		rep.Rows = append(rep.Rows, *r)
+	}
+	sort.Slice(rep.Rows, func(a, b int) bool {
+		return rep.Rows[a].Key < rep.Rows[b].Key
+	})
+	return rep
+}
+
+func (p *OnTimePerformance) key(d Dimension, s Sample) string {
+	switch d {
+	case ByStop:
+		return s.StopID
+	case ByHour:
+		h := s.Time.In(p.loc).Hour()
+		if h < 10 {
+			return "0" + strconv.Itoa(h)
+		}
+		return strconv.Itoa(h)
+	default:
+		return s.RouteID
+	}
+}
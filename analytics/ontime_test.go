@@ -0,0 +1,154 @@
+package analytics_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/Setheck/oba/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+var TestTime = time.Date(2018, time.September, 20, 8, 0, 0, 0, time.UTC)
+
+func sample(route, stop, vehicle string, at time.Time, deviation time.Duration) analytics.Sample {
+	return analytics.Sample{RouteID: route, TripID: route + "_trip", StopID: stop, VehicleID: vehicle, Time: at, Deviation: deviation}
+}
+
+// NewTestOnTimePerformance - route 1 is early, on time and late once each
+// at 08:00, route 2 is late twice at 09:00
+func NewTestOnTimePerformance() *analytics.OnTimePerformance {
+	p := analytics.NewOnTimePerformance(analytics.Thresholds{}, time.UTC)
+	p.Add(
+		sample("1", "A", "v1", TestTime, -2*time.Minute),
+		sample("1", "A", "v2", TestTime, 30*time.Second),
+		sample("1", "B", "v3", TestTime, 6*time.Minute),
+		sample("2", "B", "v4", TestTime.Add(time.Hour), 10*time.Minute),
+		sample("2", "B", "v4", TestTime.Add(time.Hour+time.Minute), 8*time.Minute),
+	)
+	return p
+}
+
+func TestThresholds_Classify(t *testing.T) {
+	th := analytics.Thresholds{Early: 30 * time.Second, Late: 2 * time.Minute}
+	assert.Equal(t, analytics.Early, th.Classify(-31*time.Second))
+	assert.Equal(t, analytics.OnTime, th.Classify(-30*time.Second))
+	assert.Equal(t, analytics.OnTime, th.Classify(2*time.Minute))
+	assert.Equal(t, analytics.Late, th.Classify(121*time.Second))
+	assert.Equal(t, analytics.Late, analytics.Thresholds{}.Classify(analytics.DefaultLate+time.Second), "defaults")
+}
+
+func TestOnTimePerformance_Report(t *testing.T) {
+	p := NewTestOnTimePerformance()
+	p.Add(sample("1", "A", "v1", TestTime, -2*time.Minute))
+	assert.Equal(t, 5, p.Len(), "repeated sample counted once")
+
+	r := p.Report(analytics.ByRoute)
+	if assert.Len(t, r.Rows, 2) {
+		assert.Equal(t, analytics.Row{Key: "1", Samples: 3, Early: 1, OnTime: 1, Late: 1, MeanDeviation: 90 * time.Second}, r.Rows[0])
+		assert.Equal(t, analytics.Row{Key: "2", Samples: 2, Late: 2, MeanDeviation: 9 * time.Minute}, r.Rows[1])
+		assert.InDelta(t, 33.3, r.Rows[0].OnTimePercent(), 0.1)
+	}
+
+	r = p.Report(analytics.ByStop)
+	if assert.Len(t, r.Rows, 2) {
+		assert.Equal(t, "A", r.Rows[0].Key)
+		assert.Equal(t, 3, r.Rows[1].Samples)
+	}
+
+	r = p.Report(analytics.ByHour)
+	if assert.Len(t, r.Rows, 2) {
+		assert.Equal(t, "08", r.Rows[0].Key)
+		assert.Equal(t, "09", r.Rows[1].Key)
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	r := NewTestOnTimePerformance().Report(analytics.ByRoute)
+
+	var buf bytes.Buffer
+	assert.NoError(t, r.WriteCSV(&buf))
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"route", "samples", "early", "on_time", "late", "on_time_pct", "mean_deviation_secs"},
+		{"1", "3", "1", "1", "1", "33.3", "90"},
+		{"2", "2", "0", "0", "2", "0.0", "540"},
+	}, records)
+
+	buf.Reset()
+	assert.NoError(t, r.WriteJSON(&buf))
+	var decoded map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, "route", decoded["dimension"])
+	assert.Equal(t, 300.0, decoded["lateThresholdSecs"])
+	assert.Len(t, decoded["rows"], 2)
+
+	buf.Reset()
+	assert.NoError(t, r.WriteTable(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[0], "on_time_pct")
+}
+
+func TestOnTimePerformance_AddVehicles(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadFile("../testdata/vehicles-for-agency.json")
+		if err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	vs, err := oba.NewDefaultClientS(server.URL, "key").VehiclesForAgency("1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	p := analytics.NewOnTimePerformance(analytics.Thresholds{}, nil)
+	p.AddVehicles(vs)
+	assert.NotZero(t, p.Len())
+	for _, s := range analytics.FromVehicles(vs) {
+		assert.NotEmpty(t, s.TripID)
+		assert.NotEmpty(t, s.VehicleID)
+	}
+
+	predicted := false
+	_, ok := analytics.FromTripStatus("1", oba.TripStatus{ActiveTripID: "t", Predicted: &predicted})
+	assert.False(t, ok, "not predicted")
+}
+
+func TestOnTimePerformance_AddTripDetails(t *testing.T) {
+	p := analytics.NewOnTimePerformance(analytics.Thresholds{}, time.UTC)
+	p.AddTripDetails([]oba.TripDetails{
+		{Trip: oba.Trip{ID: "t1", RouteID: "1"}, TripStatus: &oba.TripStatus{
+			ActiveTripID: "t1", VehicleID: "v1", ScheduleDeviation: 400, LastUpdateTime: 1537430400000}},
+		{Trip: oba.Trip{ID: "t2", RouteID: "1"}},
+	})
+	r := p.Report(analytics.ByRoute)
+	if assert.Len(t, r.Rows, 1, "details without a status are skipped") {
+		assert.Equal(t, analytics.Row{Key: "1", Samples: 1, Late: 1, MeanDeviation: 400 * time.Second}, r.Rows[0])
+	}
+}
+
+func TestOnTimePerformance_Window(t *testing.T) {
+	p := NewTestOnTimePerformance()
+	assert.Equal(t, 3, p.Prune(TestTime.Add(time.Minute)))
+	assert.Equal(t, 2, p.Len())
+
+	p = NewTestOnTimePerformance()
+	p.SetWindow(30 * time.Minute)
+	assert.Equal(t, 2, p.Len(), "samples of 08:00 are older than the window")
+	p.Add(sample("2", "B", "v4", TestTime.Add(2*time.Hour), time.Minute))
+	assert.Equal(t, 1, p.Len())
+
+	p.Add(sample("1", "A", "v1", TestTime, -2*time.Minute))
+	assert.Equal(t, 1, p.Len(), "samples before the window are dropped when added")
+}
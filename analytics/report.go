@@ -0,0 +1,131 @@
+package analytics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Row - the counts of one route, stop or hour of day
+type Row struct {
+	Key           string
+	Samples       int
+	Early         int
+	OnTime        int
+	Late          int
+	MeanDeviation time.Duration
+}
+
+// OnTimePercent - percentage of samples on time
+func (r Row) OnTimePercent() float64 {
+	if r.Samples == 0 {
+		return 0
+	}
+	return float64(r.OnTime) * 100 / float64(r.Samples)
+}
+
+// Report - on time performance grouped by a dimension
+type Report struct {
+	Dimension  Dimension
+	Thresholds Thresholds
+	Rows       []Row
+}
+
+var reportHeader = []string{"samples", "early", "on_time", "late", "on_time_pct", "mean_deviation_secs"}
+
+func (r Row) fields() []string {
+	return []string{
+		r.Key,
+		strconv.Itoa(r.Samples),
+		strconv.Itoa(r.Early),
+		strconv.Itoa(r.OnTime),
+		strconv.Itoa(r.Late),
+		strconv.FormatFloat(r.OnTimePercent(), 'f', 1, 64),
+		strconv.FormatFloat(r.MeanDeviation.Seconds(), 'f', 0, 64),
+	}
+}
+
+// WriteTable - write the report as aligned columns
+func (r Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', tabwriter.AlignRight)
+	header := append([]string{string(r.Dimension)}, reportHeader...)
+	if err := writeTabbed(tw, header); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		if err := writeTabbed(tw, row.fields()); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func writeTabbed(w io.Writer, fields []string) error {
+	for _, f := range fields {
+		if _, err := fmt.Fprint(w, f, "\t"); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// WriteCSV - write the report as csv with a header row
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(append([]string{string(r.Dimension)}, reportHeader...)); err != nil {
+		return err
+	}
+	for _, row := range r.Rows {
+		if err := cw.Write(row.fields()); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type jsonRow struct {
+	Key                  string  `json:"key"`
+	Samples              int     `json:"samples"`
+	Early                int     `json:"early"`
+	OnTime               int     `json:"onTime"`
+	Late                 int     `json:"late"`
+	OnTimePercent        float64 `json:"onTimePercent"`
+	MeanDeviationSeconds float64 `json:"meanDeviationSecs"`
+}
+
+type jsonReport struct {
+	Dimension Dimension `json:"dimension"`
+	EarlySecs float64   `json:"earlyThresholdSecs"`
+	LateSecs  float64   `json:"lateThresholdSecs"`
+	Rows      []jsonRow `json:"rows"`
+}
+
+// WriteJSON - write the report as a json object
+func (r Report) WriteJSON(w io.Writer) error {
+	jr := jsonReport{
+		Dimension: r.Dimension,
+		EarlySecs: r.Thresholds.Early.Seconds(),
+		LateSecs:  r.Thresholds.Late.Seconds(),
+		Rows:      make([]jsonRow, 0, len(r.Rows)),
+	}
+	for _, row := range r.Rows {
+		jr.Rows = append(jr.Rows, jsonRow{
+			Key:                  row.Key,
+			Samples:              row.Samples,
+			Early:                row.Early,
+			OnTime:               row.OnTime,
+			Late:                 row.Late,
+			OnTimePercent:        row.OnTimePercent(),
+			MeanDeviationSeconds: row.MeanDeviation.Seconds(),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jr)
+}
@@ -144,14 +144,16 @@ func (d AltData) String() string {
 func (d Data) toTripDetails() []TripDetails {
 	ss := d.References.Situations.toSituations()
 	ts := d.References.Trips.toTrips()
-	tds := d.List.toTripDetails(ts, ss)
+	stops := d.Stops(d.Routes(d.Agencies()))
+	tds := d.List.toTripDetails(ts, ss, stops)
 	return tds
 }
 
 func (d Data) TripDetails() *TripDetails {
 	ss := d.References.Situations.toSituations()
 	ts := d.References.Trips.toTrips()
-	td := d.Entry.ToTripDetails(ts, ss)
+	stops := d.Stops(d.Routes(d.Agencies()))
+	td := d.Entry.tripDetails(ts, ss, stops)
 	return td
 }
 
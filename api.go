@@ -2,6 +2,8 @@
 // Author: Seth T <setheck@gmail.com>
 package oba
 
+import "time"
+
 // Client - Interface for a One Bus Away Client
 type Client interface {
 	AgenciesWithCoverage() ([]AgencyWithCoverage, error)
@@ -16,17 +18,23 @@ type Client interface {
 	ReportProblemWithTrip(id string, params map[string]string) error
 	RouteIdsForAgency(id string) ([]string, error)
 	Route(id string) (*Route, error)
+	Routes(ids []string) ([]Route, error)
 	RoutesForAgency(id string) ([]Route, error)
 	RoutesForLocation(params map[string]string) ([]Route, error)
-	ScheduleForStop(id string) (*StopSchedule, error)
+	ScheduleForRoute(id string, date time.Time) (*RouteSchedule, error)
+	ScheduleForStop(id string, date time.Time) (*StopSchedule, error)
+	SearchRoute(input string, params map[string]string) ([]Route, error)
+	SearchStop(input string, params map[string]string) ([]Stop, error)
 	Shape(id string) (*Shape, error)
 	StopIDsForAgency(id string) ([]string, error)
 	Stop(id string) (*Stop, error)
+	Stops(ids []string) ([]Stop, error)
 	StopsForLocation(params map[string]string) ([]Stop, error)
 	StopsForRoute(id string) (*StopsForRoute, error)
 	TripDetails(id string) (*TripDetails, error)
 	TripForVehicle(id string, params map[string]string) (*TripDetails, error)
 	Trip(id string) (*Trip, error)
+	Trips(ids []string) ([]Trip, error)
 	TripsForLocation(params map[string]string) ([]TripDetails, error)
 	TripsForRoute(id string) ([]TripDetails, error)
 	VehiclesForAgency(id string) ([]VehicleStatus, error)
@@ -0,0 +1,229 @@
+package archive_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/Setheck/oba/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+var TestTime = time.Date(2018, time.September, 22, 12, 0, 0, 0, time.UTC)
+
+func NewTestRecords() []archive.Record {
+	return []archive.Record{
+		{
+			Kind:              archive.Vehicle,
+			Time:              TestTime,
+			Source:            "1",
+			VehicleID:         "1_3612",
+			TripID:            "1_39173425",
+			RouteID:           "1_100",
+			Lat:               47.6,
+			Lon:               -122.3,
+			Predicted:         true,
+			ScheduleDeviation: 214,
+		},
+		{
+			Kind:             archive.Arrival,
+			Time:             TestTime,
+			Source:           "1_75403",
+			TripID:           "1_39173425",
+			RouteID:          "route, with=specials",
+			StopID:           "1_75403",
+			ScheduledArrival: 1537617600000,
+			PredictedArrival: 1537617660000,
+		},
+	}
+}
+
+func TestJSONLSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := archive.NewJSONLSink(&buf)
+	assert.NoError(t, s.Write(NewTestRecords()))
+	assert.NoError(t, s.Close())
+
+	var got []archive.Record
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var r archive.Record
+		assert.NoError(t, json.Unmarshal(sc.Bytes(), &r))
+		got = append(got, r)
+	}
+	assert.Equal(t, NewTestRecords(), got)
+}
+
+func TestRotatingJSONLSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	s := archive.NewRotatingJSONLSink(dir, "vehicles", 300, 0)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, s.Write(NewTestRecords()[:1]))
+	}
+	assert.NoError(t, s.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "vehicles-*.jsonl"))
+	assert.NoError(t, err)
+	assert.True(t, len(files) > 1, "rotated by size")
+	lines := 0
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		assert.NoError(t, err)
+		assert.True(t, len(b) <= 300 || strings.Count(string(b), "\n") == 1, "size limit")
+		lines += strings.Count(string(b), "\n")
+	}
+	assert.Equal(t, 4, lines, "no records lost")
+}
+
+func TestCSVSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := archive.NewCSVSink(&buf)
+	assert.NoError(t, s.Write(NewTestRecords()))
+	assert.NoError(t, s.Write(NewTestRecords()[:1]))
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 4, "header once") {
+		assert.Equal(t, archive.CSVHeader, rows[0])
+		assert.Equal(t, "vehicle", rows[1][0])
+		assert.Equal(t, "2018-09-22T12:00:00Z", rows[1][1])
+		assert.Equal(t, "214", rows[1][10])
+		assert.Equal(t, "route, with=specials", rows[2][5])
+	}
+}
+
+func TestInfluxSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := archive.NewInfluxSink(&buf, "oba")
+	assert.NoError(t, s.Write(NewTestRecords()))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Equal(t, "oba_vehicle,route=1_100,source=1,trip=1_39173425,vehicle=1_3612 "+
+			"predicted=true,lat=47.6,lon=-122.3,schedule_deviation=214i 1537617600000000000", lines[0])
+		assert.Equal(t, `oba_arrival,route=route\,\ with\=specials,source=1_75403,stop=1_75403,trip=1_39173425 `+
+			"predicted=false,schedule_deviation=0i,scheduled_arrival_time=1537617600000i,predicted_arrival_time=1537617660000i "+
+			"1537617600000000000", lines[1])
+	}
+}
+
+func TestRotatingCSVSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	s := archive.NewRotatingCSVSink(dir, "vehicles", 400, 0)
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, s.Write(NewTestRecords()[:1]))
+	}
+	assert.NoError(t, s.Close())
+
+	files, err := filepath.Glob(filepath.Join(dir, "vehicles-*.csv"))
+	assert.NoError(t, err)
+	assert.True(t, len(files) > 1, "rotated by size")
+	rows := 0
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		assert.NoError(t, err)
+		records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+		if assert.NoError(t, err) && assert.True(t, len(records) > 1, "header and rows") {
+			assert.Equal(t, archive.CSVHeader, records[0], "header per file")
+			rows += len(records) - 1
+		}
+	}
+	assert.Equal(t, 4, rows, "no records lost")
+}
+
+func TestInfluxSink_Backslash(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewTestRecords()[0]
+	r.RouteID = `1\100, x`
+	assert.NoError(t, archive.NewInfluxSink(&buf, "oba").Write([]archive.Record{r}))
+	assert.Contains(t, buf.String(), `,route=1\\100\,\ x,`)
+}
+
+// memorySink - collects records and counts closes
+type memorySink struct {
+	mu      sync.Mutex
+	records []archive.Record
+	closed  int
+}
+
+func (m *memorySink) Write(rs []archive.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, rs...)
+	return nil
+}
+
+func (m *memorySink) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closed++
+	return nil
+}
+
+func TestRecorder_Run(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file := "../testdata/vehicles-for-agency.json"
+		if strings.Contains(r.URL.Path, "arrivals-and-departures-for-stop") {
+			file = "../testdata/arrivals-and-departures-for-stop.json"
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	sink := &memorySink{}
+	var errs []error
+	rec := archive.NewRecorder(oba.NewDefaultClientS(server.URL, "key"), 10*time.Millisecond, sink).
+		Vehicles("1").
+		Arrivals("1_75403")
+	rec.OnError = func(err error) { errs = append(errs, err) }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 35*time.Millisecond)
+	defer cancel()
+	assert.NoError(t, rec.Run(ctx))
+	assert.Empty(t, errs)
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	assert.Equal(t, 1, sink.closed, "closed on shutdown")
+	var vehicles, arrivals int
+	for _, r := range sink.records {
+		switch r.Kind {
+		case archive.Vehicle:
+			vehicles++
+			assert.Equal(t, "1", r.Source)
+		case archive.Arrival:
+			arrivals++
+			assert.Equal(t, "1_75403", r.Source)
+		}
+	}
+	assert.True(t, vehicles > 0, "vehicles")
+	assert.True(t, arrivals > 0, "arrivals")
+
+	assert.Error(t, archive.NewRecorder(nil, 0).Run(ctx), "interval")
+}
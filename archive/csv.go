@@ -0,0 +1,93 @@
+package archive
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// CSVHeader - the columns written by CSVSink
+var CSVHeader = []string{
+	"kind", "time", "source", "vehicle_id", "trip_id", "route_id", "stop_id",
+	"lat", "lon", "predicted", "schedule_deviation", "distance_along_trip",
+	"last_update_time", "scheduled_arrival_time", "predicted_arrival_time",
+	"scheduled_departure_time", "predicted_departure_time",
+}
+
+// CSVSink - writes records as csv rows. A sink over a single writer writes
+// CSVHeader once before the first rows, a rotating sink starts every file with
+// it.
+type CSVSink struct {
+	w      io.Writer
+	header bool
+}
+
+// NewCSVSink - a sink writing to w, closing w on Close if it is an io.Closer
+func NewCSVSink(w io.Writer) *CSVSink {
+	return &CSVSink{w: w}
+}
+
+// NewRotatingCSVSink - a sink writing to files in dir, see RotatingFile
+func NewRotatingCSVSink(dir, prefix string, maxBytes int64, interval time.Duration) *CSVSink {
+	f := NewRotatingFile(dir, prefix, ".csv", maxBytes, interval)
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.Write(CSVHeader)
+	cw.Flush()
+	f.header = buf.Bytes()
+	return &CSVSink{w: f, header: true}
+}
+
+// Write - write rs with a single write to the underlying writer, so a
+// rotating file never splits a row
+func (s *CSVSink) Write(rs []Record) error {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	if !s.header {
+		if err := cw.Write(CSVHeader); err != nil {
+			return err
+		}
+	}
+	for _, r := range rs {
+		if err := cw.Write(csvFields(r)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	s.header = true
+	return nil
+}
+
+func (s *CSVSink) Close() error {
+	return closeWriter(s.w)
+}
+
+func csvFields(r Record) []string {
+	return []string{
+		string(r.Kind),
+		r.Time.Format(time.RFC3339Nano),
+		r.Source,
+		r.VehicleID,
+		r.TripID,
+		r.RouteID,
+		r.StopID,
+		strconv.FormatFloat(r.Lat, 'f', -1, 64),
+		strconv.FormatFloat(r.Lon, 'f', -1, 64),
+		strconv.FormatBool(r.Predicted),
+		strconv.Itoa(r.ScheduleDeviation),
+		strconv.FormatFloat(r.DistanceAlongTrip, 'f', -1, 64),
+		strconv.Itoa(r.LastUpdateTime),
+		strconv.Itoa(r.ScheduledArrival),
+		strconv.Itoa(r.PredictedArrival),
+		strconv.Itoa(r.ScheduledDeparture),
+		strconv.Itoa(r.PredictedDeparture),
+	}
+}
@@ -0,0 +1,100 @@
+package archive
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// InfluxSink - writes records as InfluxDB line protocol, one point per record
+// in the measurement <prefix>_<kind> with nanosecond timestamps, e.g.
+//
+//	oba_vehicle,route=1_100,source=1,vehicle=1_3612 predicted=true,lat=47.6,lon=-122.3,schedule_deviation=214i 1537616515000000000
+type InfluxSink struct {
+	w      io.Writer
+	bw     *bufio.Writer
+	prefix string
+}
+
+// NewInfluxSink - a sink writing measurements named with prefix to w, closing
+// w on Close if it is an io.Closer
+func NewInfluxSink(w io.Writer, prefix string) *InfluxSink {
+	return &InfluxSink{w: w, bw: bufio.NewWriter(w), prefix: prefix}
+}
+
+func (s *InfluxSink) Write(rs []Record) error {
+	for _, r := range rs {
+		if _, err := s.bw.WriteString(s.line(r)); err != nil {
+			return err
+		}
+	}
+	return s.bw.Flush()
+}
+
+func (s *InfluxSink) Close() error {
+	return closeWriter(s.w)
+}
+
+func (s *InfluxSink) line(r Record) string {
+	var b strings.Builder
+	b.WriteString(influxEscape(s.prefix+"_"+string(r.Kind), ", "))
+
+	// tags in key order
+	for _, t := range [][2]string{
+		{"route", r.RouteID},
+		{"source", r.Source},
+		{"stop", r.StopID},
+		{"trip", r.TripID},
+		{"vehicle", r.VehicleID},
+	} {
+		if t[1] != "" {
+			b.WriteString("," + t[0] + "=" + influxEscape(t[1], ", ="))
+		}
+	}
+
+	fields := []string{"predicted=" + strconv.FormatBool(r.Predicted)}
+	if r.Lat != 0 || r.Lon != 0 {
+		fields = append(fields,
+			"lat="+strconv.FormatFloat(r.Lat, 'f', -1, 64),
+			"lon="+strconv.FormatFloat(r.Lon, 'f', -1, 64))
+	}
+	fields = append(fields, "schedule_deviation="+strconv.Itoa(r.ScheduleDeviation)+"i")
+	if r.DistanceAlongTrip != 0 {
+		fields = append(fields, "distance_along_trip="+strconv.FormatFloat(r.DistanceAlongTrip, 'f', -1, 64))
+	}
+	for _, f := range []struct {
+		key string
+		ms  int
+	}{
+		{"last_update_time", r.LastUpdateTime},
+		{"scheduled_arrival_time", r.ScheduledArrival},
+		{"predicted_arrival_time", r.PredictedArrival},
+		{"scheduled_departure_time", r.ScheduledDeparture},
+		{"predicted_departure_time", r.PredictedDeparture},
+	} {
+		if f.ms != 0 {
+			fields = append(fields, f.key+"="+strconv.Itoa(f.ms)+"i")
+		}
+	}
+
+	b.WriteString(" " + strings.Join(fields, ","))
+	b.WriteString(" " + strconv.FormatInt(r.Time.UnixNano(), 10) + "\n")
+	return b.String()
+}
+
+// influxEscape - backslash escape backslashes and each of chars in s
+func influxEscape(s, chars string) string {
+	chars += `\`
+	if !strings.ContainsAny(s, chars) {
+		return s
+	}
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(chars, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package archive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// JSONLSink - writes one json object per record per line
+type JSONLSink struct {
+	w   io.Writer
+	enc *json.Encoder
+}
+
+// NewJSONLSink - a sink writing to w, closing w on Close if it is an io.Closer
+func NewJSONLSink(w io.Writer) *JSONLSink {
+	return &JSONLSink{w: w, enc: json.NewEncoder(w)}
+}
+
+// NewRotatingJSONLSink - a sink writing to files in dir, see RotatingFile
+func NewRotatingJSONLSink(dir, prefix string, maxBytes int64, interval time.Duration) *JSONLSink {
+	return NewJSONLSink(NewRotatingFile(dir, prefix, ".jsonl", maxBytes, interval))
+}
+
+func (s *JSONLSink) Write(rs []Record) error {
+	for _, r := range rs {
+		if err := s.enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *JSONLSink) Close() error {
+	return closeWriter(s.w)
+}
+
+func closeWriter(w io.Writer) error {
+	if c, ok := w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// RotatingFile - an io.WriteCloser over a series of files named
+// <prefix>-<time><ext> in a directory. A new file is started when the current
+// one would grow past maxBytes or is older than interval; zero disables either.
+// A single Write is never split across files.
+type RotatingFile struct {
+	dir, prefix, ext string
+	maxBytes         int64
+	interval         time.Duration
+	now              func() time.Time
+
+	// header starts each file, as the csv header of a rotating csv sink
+	header []byte
+
+	f       *os.File
+	size    int64
+	started time.Time
+}
+
+// NewRotatingFile - a rotating file in dir, the directory is created on the
+// first write
+func NewRotatingFile(dir, prefix, ext string, maxBytes int64, interval time.Duration) *RotatingFile {
+	return &RotatingFile{
+		dir:      dir,
+		prefix:   prefix,
+		ext:      ext,
+		maxBytes: maxBytes,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	now := r.now()
+	if r.f != nil && r.due(now, len(p)) {
+		if err := r.Close(); err != nil {
+			return 0, err
+		}
+	}
+	if r.f == nil {
+		if err := r.open(now); err != nil {
+			return 0, err
+		}
+	}
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *RotatingFile) due(now time.Time, n int) bool {
+	if r.maxBytes > 0 && r.size > int64(len(r.header)) && r.size+int64(n) > r.maxBytes {
+		return true
+	}
+	return r.interval > 0 && now.Sub(r.started) >= r.interval
+}
+
+func (r *RotatingFile) open(now time.Time) error {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	base := fmt.Sprint(r.prefix, "-", now.UTC().Format("20060102T150405"))
+	name := filepath.Join(r.dir, base+r.ext)
+	for i := 1; fileExists(name); i++ {
+		name = filepath.Join(r.dir, fmt.Sprint(base, ".", i, r.ext))
+	}
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	r.f, r.size, r.started = f, 0, now
+	if len(r.header) > 0 {
+		n, err := f.Write(r.header)
+		r.size += int64(n)
+		return err
+	}
+	return nil
+}
+
+// Name - the path of the current file, empty before the first write
+func (r *RotatingFile) Name() string {
+	if r.f == nil {
+		return ""
+	}
+	return r.f.Name()
+}
+
+// Close - close the current file, a later Write starts a new one
+func (r *RotatingFile) Close() error {
+	if r.f == nil {
+		return nil
+	}
+	err := r.f.Close()
+	r.f = nil
+	return err
+}
+
+func fileExists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,108 @@
+// Package archive - record polled One Bus Away real time data to files for
+// later analysis
+package archive
+
+import (
+	"time"
+
+	"github.com/Setheck/oba"
+)
+
+// Kind - what a record describes
+type Kind string
+
+const (
+	Vehicle Kind = "vehicle"
+	Arrival Kind = "arrival"
+)
+
+// Record - one vehicle position or arrival prediction. Times from the server
+// are kept in milliseconds since the epoch as in the oba package, zero when
+// unknown.
+type Record struct {
+	Kind Kind `json:"kind"`
+	// Time - when the record was polled
+	Time time.Time `json:"time"`
+	// Source - the agency or stop id that was polled
+	Source             string  `json:"source"`
+	VehicleID          string  `json:"vehicleId,omitempty"`
+	TripID             string  `json:"tripId,omitempty"`
+	RouteID            string  `json:"routeId,omitempty"`
+	StopID             string  `json:"stopId,omitempty"`
+	Lat                float64 `json:"lat,omitempty"`
+	Lon                float64 `json:"lon,omitempty"`
+	Predicted          bool    `json:"predicted"`
+	ScheduleDeviation  int     `json:"scheduleDeviation"`
+	DistanceAlongTrip  float64 `json:"distanceAlongTrip,omitempty"`
+	LastUpdateTime     int     `json:"lastUpdateTime,omitempty"`
+	ScheduledArrival   int     `json:"scheduledArrivalTime,omitempty"`
+	PredictedArrival   int     `json:"predictedArrivalTime,omitempty"`
+	ScheduledDeparture int     `json:"scheduledDepartureTime,omitempty"`
+	PredictedDeparture int     `json:"predictedDepartureTime,omitempty"`
+}
+
+// Sink - a destination for records. Write is called from a single goroutine.
+type Sink interface {
+	Write(rs []Record) error
+	Close() error
+}
+
+// FromVehicles - vehicle records of a VehiclesForAgency result for agencyID
+func FromVehicles(agencyID string, at time.Time, vs []oba.VehicleStatus) []Record {
+	rs := make([]Record, 0, len(vs))
+	for _, v := range vs {
+		ts := v.TripStatus
+		rs = append(rs, Record{
+			Kind:              Vehicle,
+			Time:              at,
+			Source:            agencyID,
+			VehicleID:         v.VehicleID,
+			TripID:            v.Trip.ID,
+			RouteID:           v.Trip.RouteID,
+			StopID:            ts.ClosestStop.ID,
+			Lat:               v.Location.Lat,
+			Lon:               v.Location.Lon,
+			Predicted:         ts.Predicted != nil && *ts.Predicted,
+			ScheduleDeviation: ts.ScheduleDeviation,
+			DistanceAlongTrip: ts.DistanceAlongTrip,
+			LastUpdateTime:    v.LastUpdateTime,
+		})
+	}
+	return rs
+}
+
+// FromArrivals - arrival records of an ArrivalsAndDeparturesForStop result
+func FromArrivals(at time.Time, s *oba.StopWithArrivalsAndDepartures) []Record {
+	if s == nil {
+		return nil
+	}
+	rs := make([]Record, 0, len(s.ArrivalsAndDepartures))
+	for _, a := range s.ArrivalsAndDepartures {
+		r := Record{
+			Kind:               Arrival,
+			Time:               at,
+			Source:             s.StopID,
+			VehicleID:          a.VehicleID,
+			TripID:             a.TripID,
+			RouteID:            a.RouteID,
+			StopID:             a.StopID,
+			Predicted:          a.Predicted != nil && *a.Predicted,
+			LastUpdateTime:     a.LastUpdateTime,
+			ScheduledArrival:   a.ScheduledArrivalTime,
+			PredictedArrival:   a.PredictedArrivalTime,
+			ScheduledDeparture: a.ScheduledDepartureTime,
+			PredictedDeparture: a.PredictedDepartureTime,
+		}
+		if r.StopID == "" {
+			r.StopID = s.StopID
+		}
+		if a.TripStatus != nil {
+			r.Lat = a.TripStatus.Position.Lat
+			r.Lon = a.TripStatus.Position.Lon
+			r.ScheduleDeviation = a.TripStatus.ScheduleDeviation
+			r.DistanceAlongTrip = a.TripStatus.DistanceAlongTrip
+		}
+		rs = append(rs, r)
+	}
+	return rs
+}
@@ -0,0 +1,109 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Setheck/oba"
+)
+
+// Recorder - polls agencies for vehicles and stops for arrivals on an interval
+// and writes the records to every sink
+type Recorder struct {
+	client   oba.Client
+	interval time.Duration
+	sinks    []Sink
+	agencies []string
+	stops    []string
+	// OnError - called with failed polls and writes, which do not stop the
+	// recorder
+	OnError func(error)
+	now     func() time.Time
+}
+
+// NewRecorder - a recorder polling with client every interval
+func NewRecorder(client oba.Client, interval time.Duration, sinks ...Sink) *Recorder {
+	return &Recorder{
+		client:   client,
+		interval: interval,
+		sinks:    sinks,
+		now:      time.Now,
+	}
+}
+
+// Vehicles - poll VehiclesForAgency for each agency
+func (r *Recorder) Vehicles(agencyIDs ...string) *Recorder {
+	r.agencies = append(r.agencies, agencyIDs...)
+	return r
+}
+
+// Arrivals - poll ArrivalsAndDeparturesForStop for each stop
+func (r *Recorder) Arrivals(stopIDs ...string) *Recorder {
+	r.stops = append(r.stops, stopIDs...)
+	return r
+}
+
+// Poll - poll every agency and stop once and write the records
+func (r *Recorder) Poll() {
+	var rs []Record
+	for _, id := range r.agencies {
+		vs, err := r.client.VehiclesForAgency(id)
+		if err != nil {
+			r.error(err)
+			continue
+		}
+		rs = append(rs, FromVehicles(id, r.now(), vs)...)
+	}
+	for _, id := range r.stops {
+		s, err := r.client.ArrivalsAndDeparturesForStop(id, nil)
+		if err != nil {
+			r.error(err)
+			continue
+		}
+		rs = append(rs, FromArrivals(r.now(), s)...)
+	}
+	if len(rs) == 0 {
+		return
+	}
+	for _, s := range r.sinks {
+		if err := s.Write(rs); err != nil {
+			r.error(err)
+		}
+	}
+}
+
+// Run - poll immediately and then every interval until ctx is done. A poll in
+// progress is finished and the sinks are closed before Run returns. Run
+// returns nil on cancellation, or the first error closing a sink.
+func (r *Recorder) Run(ctx context.Context) error {
+	if r.interval <= 0 {
+		return errors.New("archive: recorder interval must be positive")
+	}
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		r.Poll()
+		select {
+		case <-ctx.Done():
+			return r.close()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Recorder) close() error {
+	var first error
+	for _, s := range r.sinks {
+		if err := s.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (r *Recorder) error(err error) {
+	if r.OnError != nil {
+		r.OnError(err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package oba - One Bus Away Go Api https://onebusaway.org/
+// Author: Seth T <setheck@gmail.com>
+package oba
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const defaultBatchWorkers = 4
+
+// BatchError - the per id failures of a batch fetch. The batch methods return
+// the results they could fetch alongside a BatchError for the rest.
+type BatchError map[string]error
+
+func (b BatchError) Error() string {
+	ids := make([]string, 0, len(b))
+	for id := range b {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	msgs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", id, b[id]))
+	}
+	return fmt.Sprintf("%d of batch failed: %s", len(b), strings.Join(msgs, "; "))
+}
+
+// Stops - fetch many stops concurrently, see Stop
+// Duplicate ids are fetched once and results keep the order of ids.
+func (c DefaultClient) Stops(ids []string) ([]Stop, error) {
+	ids = uniqueIDs(ids)
+	found := make([]*Stop, len(ids))
+	err := c.batch(ids, func(i int, id string) (err error) {
+		found[i], err = c.Stop(id)
+		return err
+	})
+	stops := make([]Stop, 0, len(found))
+	for _, s := range found {
+		if s != nil {
+			stops = append(stops, *s)
+		}
+	}
+	return stops, err
+}
+
+// Routes - fetch many routes concurrently, see Route
+// Duplicate ids are fetched once and results keep the order of ids.
+func (c DefaultClient) Routes(ids []string) ([]Route, error) {
+	ids = uniqueIDs(ids)
+	found := make([]*Route, len(ids))
+	err := c.batch(ids, func(i int, id string) (err error) {
+		found[i], err = c.Route(id)
+		return err
+	})
+	routes := make([]Route, 0, len(found))
+	for _, r := range found {
+		if r != nil {
+			routes = append(routes, *r)
+		}
+	}
+	return routes, err
+}
+
+// Trips - fetch many trips concurrently, see Trip
+// Duplicate ids are fetched once and results keep the order of ids.
+func (c DefaultClient) Trips(ids []string) ([]Trip, error) {
+	ids = uniqueIDs(ids)
+	found := make([]*Trip, len(ids))
+	err := c.batch(ids, func(i int, id string) (err error) {
+		found[i], err = c.Trip(id)
+		return err
+	})
+	trips := make([]Trip, 0, len(found))
+	for _, t := range found {
+		if t != nil {
+			trips = append(trips, *t)
+		}
+	}
+	return trips, err
+}
+
+// batch - run fetch for every id on a bounded pool of workers, collecting the
+// failures into a BatchError
+func (c DefaultClient) batch(ids []string, fetch func(i int, id string) error) error {
+	workers := c.batchWorkers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+	if workers > len(ids) {
+		workers = len(ids)
+	}
+
+	var mu sync.Mutex
+	errs := make(BatchError)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if err := fetch(i, ids[i]); err != nil {
+					mu.Lock()
+					errs[ids[i]] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range ids {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// uniqueIDs - ids without duplicates or blanks, in first seen order
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	uniq := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		uniq = append(uniq, id)
+	}
+	return uniq
+}
@@ -0,0 +1,120 @@
+package oba_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+const TestNotFound = `{"code":404,"currentTime":1537502266608,"text":"resource not found","version":2}`
+
+// BatchServer - serves body for any id except "missing", counting requests per path
+func BatchServer(t *testing.T, body []byte, counts map[string]int, mu *sync.Mutex) *httptest.Server {
+	t.Helper()
+	handler := http.HandlerFunc(func(r http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		counts[req.URL.Path]++
+		mu.Unlock()
+		resp := body
+		if strings.Contains(req.URL.Path, "missing") {
+			resp = []byte(TestNotFound)
+		}
+		if _, err := r.Write(resp); err != nil {
+			t.Error(err)
+		}
+	})
+	return httptest.NewServer(handler)
+}
+
+func TestDefaultClient_Stops(t *testing.T) {
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	server := BatchServer(t, ReadFile(t, "stop.json"), counts, &mu)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+	client.SetBatchWorkers(2)
+
+	stops, err := client.Stops([]string{"1", "2", "1", "", "3", "2"})
+	assert.NoError(t, err)
+	assert.Len(t, stops, 3, "Stops")
+	for _, s := range stops {
+		VerifyStop(t, &s)
+	}
+	assert.Len(t, counts, 3, "requests")
+	for path, n := range counts {
+		assert.Equal(t, 1, n, path)
+	}
+}
+
+func TestDefaultClient_StopsPartial(t *testing.T) {
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	server := BatchServer(t, ReadFile(t, "stop.json"), counts, &mu)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	stops, err := client.Stops([]string{"1", "missing", "2"})
+	assert.Len(t, stops, 2, "Stops")
+	if assert.Error(t, err) {
+		be, ok := err.(oba.BatchError)
+		assert.True(t, ok, "BatchError")
+		assert.Len(t, be, 1, "BatchError")
+		assert.Contains(t, be, "missing")
+	}
+}
+
+func TestDefaultClient_Routes(t *testing.T) {
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	server := BatchServer(t, ReadFile(t, "route.json"), counts, &mu)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	routes, err := client.Routes([]string{"1", "2", "2"})
+	assert.NoError(t, err)
+	assert.Len(t, routes, 2, "Routes")
+	for _, r := range routes {
+		VerifyRoute(t, &r)
+	}
+}
+
+func TestDefaultClient_Trips(t *testing.T) {
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	server := BatchServer(t, ReadFile(t, "trip.json"), counts, &mu)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	trips, err := client.Trips([]string{"1", "2", "3", "missing"})
+	assert.Error(t, err)
+	assert.Len(t, trips, 3, "Trips")
+	for _, tr := range trips {
+		VerifyTrip(t, &tr)
+	}
+}
+
+func TestDefaultClient_SetRateLimit(t *testing.T) {
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	server := BatchServer(t, ReadFile(t, "stop.json"), counts, &mu)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+	client.SetRateLimit(50)
+	client.SetBatchWorkers(5)
+
+	start := time.Now()
+	_, err := client.Stops([]string{"1", "2", "3", "4", "5"})
+	assert.NoError(t, err)
+	assert.True(t, time.Since(start) >= 80*time.Millisecond, "rate limited")
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/Setheck/oba"
 	"github.com/spf13/cobra"
 )
@@ -17,38 +15,34 @@ var agencyCmd = &cobra.Command{
 	Short: "retrieve agencies",
 	Long:  "get some agencies",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := oba.NewDefaultClientS(baseUrl, apiKey)
+		client := newClient()
 		coverage, err := cmd.Flags().GetBool("coverage")
 		if err != nil {
 			return err
 		}
 		if coverage {
-			return AgenciesWithCoverage(client)
+			return AgenciesWithCoverage(cmd, client)
 		}
 		id, err := cmd.Flags().GetString("id")
 		if err != nil {
 			return err
 		}
-		return Agency(client, id)
+		return Agency(cmd, client, id)
 	},
 }
 
-func Agency(client oba.Client, id string) error {
+func Agency(cmd *cobra.Command, client oba.Client, id string) error {
 	agency, err := client.Agency(id)
 	if err != nil {
 		return err
 	}
-	fmt.Println(agency.String())
-	return nil
+	return output(cmd, agency)
 }
 
-func AgenciesWithCoverage(client oba.Client) error {
+func AgenciesWithCoverage(cmd *cobra.Command, client oba.Client) error {
 	awc, err := client.AgenciesWithCoverage()
 	if err != nil {
 		return err
 	}
-	for _, a := range awc {
-		fmt.Println(a)
-	}
-	return nil
+	return output(cmd, awc)
 }
@@ -0,0 +1,291 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+)
+
+// alarmPath - the path of the callback, the server replaces #ALARM_ID#
+const alarmPath = "/alarm/"
+
+func init() {
+	alarmSetCmd.Flags().String("stop", "", "stop id")
+	alarmSetCmd.Flags().String("trip", "", "trip id")
+	alarmSetCmd.Flags().String("service-date", "", "service date of the trip as YYYY-MM-DD or milliseconds since the epoch")
+	alarmSetCmd.Flags().String("vehicle", "", "vehicle id, optional")
+	alarmSetCmd.Flags().Int("stop-sequence", -1, "stop sequence of the stop in the trip, optional")
+	alarmSetCmd.Flags().Int("offset", 300, "fire the alarm this many seconds before the departure")
+	alarmSetCmd.Flags().Bool("on-arrival", false, "fire relative to the arrival instead of the departure")
+	alarmSetCmd.Flags().String("listen", "127.0.0.1:0", "address of the local callback listener")
+	alarmSetCmd.Flags().String("callback-url", "", "url the server calls back, required unless --listen "+
+		"is an address the server can reach")
+	alarmSetCmd.Flags().String("exec", "", "command run by the shell when the alarm fires, OBA_ALARM_ID holds the alarm id")
+	alarmCmd.AddCommand(alarmSetCmd, alarmCancelCmd)
+	rootCmd.AddCommand(alarmCmd)
+}
+
+var alarmCmd = &cobra.Command{
+	Use:   "alarm",
+	Short: "arrival and departure alarms",
+	Long:  "register alarms that call back before a vehicle arrives or departs a stop",
+}
+
+var alarmSetCmd = &cobra.Command{
+	Use:   "set",
+	Short: "set an alarm and wait for it",
+	Long: "register an alarm for an arrival or departure at a stop and wait for the callback, " +
+		"the alarm is cancelled when interrupted",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		params, stop, err := alarmParams(cmd)
+		if err != nil {
+			return err
+		}
+		listen, err := cmd.Flags().GetString("listen")
+		if err != nil {
+			return err
+		}
+		callback, err := cmd.Flags().GetString("callback-url")
+		if err != nil {
+			return err
+		}
+		command, err := cmd.Flags().GetString("exec")
+		if err != nil {
+			return err
+		}
+
+		ln, err := net.Listen("tcp", listen)
+		if err != nil {
+			return err
+		}
+		if callback == "" {
+			if unreachable(ln.Addr()) {
+				ln.Close()
+				return fmt.Errorf("--callback-url is required, the server cannot call back %s", ln.Addr())
+			}
+			callback = "http://" + ln.Addr().String()
+		}
+		l := &alarmListener{fired: make(chan struct{}, 1)}
+		srv := &http.Server{Handler: l}
+		go srv.Serve(ln)
+		defer srv.Shutdown(context.Background())
+
+		params["url"] = strings.TrimSuffix(callback, "/") + alarmPath + "#ALARM_ID#"
+		ra, err := client.RegisterAlarmForArrivalAndDepartureAtStop(stop, params)
+		if err != nil {
+			return err
+		}
+		l.expect(ra.AlarmID)
+		fmt.Fprintf(cmd.OutOrStderr(), "alarm %s set, waiting for %s (ctrl-c cancels)\n", ra.AlarmID, params["url"])
+
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		defer signal.Stop(interrupt)
+		select {
+		case <-interrupt:
+			if err := client.CancelAlarm(ra.AlarmID); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStderr(), "alarm %s cancelled\n", ra.AlarmID)
+			return nil
+		case <-l.fired:
+			return alarmFired(cmd, ra.AlarmID, command)
+		}
+	},
+}
+
+var alarmCancelCmd = &cobra.Command{
+	Use:   "cancel <id>",
+	Short: "cancel an alarm",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		if err := client.CancelAlarm(args[0]); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "alarm %s cancelled\n", args[0])
+		return nil
+	},
+}
+
+// alarmParams - the register alarm request parameters and the stop id from
+// the flags of cmd
+func alarmParams(cmd *cobra.Command) (map[string]string, string, error) {
+	stop, err := cmd.Flags().GetString("stop")
+	if err != nil {
+		return nil, "", err
+	}
+	trip, err := cmd.Flags().GetString("trip")
+	if err != nil {
+		return nil, "", err
+	}
+	date, err := cmd.Flags().GetString("service-date")
+	if err != nil {
+		return nil, "", err
+	}
+	if stop == "" || trip == "" || date == "" {
+		return nil, "", fmt.Errorf("--stop, --trip and --service-date are required")
+	}
+	serviceDate, err := serviceDateMillis(date)
+	if err != nil {
+		return nil, "", err
+	}
+	offset, err := cmd.Flags().GetInt("offset")
+	if err != nil {
+		return nil, "", err
+	}
+	onArrival, err := cmd.Flags().GetBool("on-arrival")
+	if err != nil {
+		return nil, "", err
+	}
+	params := map[string]string{
+		"tripId":          trip,
+		"serviceDate":     serviceDate,
+		"alarmTimeOffset": strconv.Itoa(offset),
+		"onArrival":       strconv.FormatBool(onArrival),
+	}
+	vehicle, err := cmd.Flags().GetString("vehicle")
+	if err != nil {
+		return nil, "", err
+	}
+	if vehicle != "" {
+		params["vehicleId"] = vehicle
+	}
+	seq, err := cmd.Flags().GetInt("stop-sequence")
+	if err != nil {
+		return nil, "", err
+	}
+	if seq >= 0 {
+		params["stopSequence"] = strconv.Itoa(seq)
+	}
+	return params, stop, nil
+}
+
+// serviceDateMillis - a service date as YYYY-MM-DD in local time, or already
+// in milliseconds, as milliseconds since the epoch
+func serviceDateMillis(s string) (string, error) {
+	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return s, nil
+	}
+	d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return "", fmt.Errorf("invalid --service-date %q, use YYYY-MM-DD or milliseconds", s)
+	}
+	return strconv.FormatInt(d.UnixNano()/int64(time.Millisecond), 10), nil
+}
+
+// unreachable - whether the server cannot call back a listener at addr, a
+// loopback or unspecified address is only reachable by a server on the same
+// host
+func unreachable(addr net.Addr) bool {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok || !(tcp.IP.IsLoopback() || tcp.IP.IsUnspecified()) {
+		return false
+	}
+	u, err := url.Parse(baseUrl)
+	if err != nil {
+		return true
+	}
+	host := u.Hostname()
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
+// maxEarlyAlarms - how many callbacks arriving before the alarm id is known
+// are kept
+const maxEarlyAlarms = 16
+
+// alarmListener - accepts the callback of the registered alarm, other paths
+// and alarm ids are not found. Callbacks arriving while the alarm is being
+// registered are kept until its id is known.
+type alarmListener struct {
+	mu    sync.Mutex
+	id    string
+	early map[string]bool
+	fired chan struct{}
+}
+
+// expect - accept callbacks for alarm id, firing if one already arrived
+func (l *alarmListener) expect(id string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.id = id
+	if l.early[id] {
+		l.fire()
+	}
+	l.early = nil
+}
+
+func (l *alarmListener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id, ok := alarmID(r.URL.Path)
+	switch {
+	case !ok:
+		http.NotFound(w, r)
+		return
+	case l.id == "":
+		if len(l.early) >= maxEarlyAlarms {
+			http.NotFound(w, r)
+			return
+		}
+		if l.early == nil {
+			l.early = make(map[string]bool)
+		}
+		l.early[id] = true
+	case id == l.id:
+		l.fire()
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// alarmID - the alarm id of a callback path ending in alarmPath and the id,
+// the callback url may add a prefix
+func alarmID(p string) (string, bool) {
+	i := strings.LastIndex(p, alarmPath)
+	if i < 0 {
+		return "", false
+	}
+	id := p[i+len(alarmPath):]
+	return id, id != "" && !strings.Contains(id, "/")
+}
+
+// fire - signal the alarm without waiting, it fires once
+func (l *alarmListener) fire() {
+	select {
+	case l.fired <- struct{}{}:
+	default:
+	}
+}
+
+// alarmFired - run command, or print a notification without one
+func alarmFired(cmd *cobra.Command, id, command string) error {
+	logger.Log(oba.LevelInfo, "alarm fired", oba.F("alarm", id))
+	if command == "" {
+		fmt.Fprintf(cmd.OutOrStdout(), "\aalarm %s fired at %s\n", id, now().Format("15:04:05"))
+		return nil
+	}
+	c := exec.Command("sh", "-c", command)
+	c.Env = append(os.Environ(), "OBA_ALARM_ID="+id)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, cmd.OutOrStdout(), cmd.OutOrStderr()
+	return c.Run()
+}
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlarmListener(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect string
+		paths  []string
+		codes  []int
+		fired  bool
+	}{
+		{"registered id", "1_a", []string{"/alarm/1_a"}, []int{200}, true},
+		{"callback url prefix", "1_a", []string{"/oba/alarm/1_a"}, []int{200}, true},
+		{"other id", "1_a", []string{"/alarm/1_b"}, []int{404}, false},
+		{"other path", "1_a", []string{"/1_a", "/alarm/", "/alarm/1_a/x"}, []int{404, 404, 404}, false},
+		{"before registration", "", []string{"/alarm/1_a"}, []int{200}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			l := &alarmListener{fired: make(chan struct{}, 1)}
+			if test.expect != "" {
+				l.expect(test.expect)
+			}
+			for i, p := range test.paths {
+				w := httptest.NewRecorder()
+				l.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+				assert.Equal(t, test.codes[i], w.Code, p)
+			}
+			assert.Equal(t, test.fired, len(l.fired) == 1, "fired")
+		})
+	}
+}
+
+func TestAlarmListener_Early(t *testing.T) {
+	l := &alarmListener{fired: make(chan struct{}, 1)}
+	for _, p := range []string{"/alarm/1_b", "/alarm/1_a"} {
+		w := httptest.NewRecorder()
+		l.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+		assert.Equal(t, http.StatusOK, w.Code, p)
+	}
+	assert.Len(t, l.fired, 0, "id not known yet")
+
+	l.expect("1_a")
+	assert.Len(t, l.fired, 1, "early callback fires once the id is known")
+	assert.Nil(t, l.early)
+
+	w := httptest.NewRecorder()
+	l.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/alarm/1_b", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code, "other ids after registration")
+}
+
+func TestUnreachable(t *testing.T) {
+	defer func(u string) { baseUrl = u }(baseUrl)
+	tests := []struct {
+		name   string
+		listen string
+		base   string
+		want   bool
+	}{
+		{"loopback, remote server", "127.0.0.1", "http://api.pugetsound.onebusaway.org/api/where/", true},
+		{"unspecified, remote server", "0.0.0.0", "http://api.pugetsound.onebusaway.org/api/where/", true},
+		{"loopback, local server", "127.0.0.1", "http://127.0.0.1:8080/api/where/", false},
+		{"loopback, localhost server", "::1", "http://localhost:8080/api/where/", false},
+		{"routable address", "10.0.0.5", "http://api.pugetsound.onebusaway.org/api/where/", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			baseUrl = test.base
+			addr := &net.TCPAddr{IP: net.ParseIP(test.listen), Port: 8080}
+			assert.Equal(t, test.want, unreachable(addr))
+		})
+	}
+}
+
+func TestServiceDateMillis(t *testing.T) {
+	day := time.Date(2018, time.September, 20, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		date string
+		want string
+		err  bool
+	}{
+		{"1537426800000", "1537426800000", false},
+		{"2018-09-20", strconv.FormatInt(day.UnixNano()/int64(time.Millisecond), 10), false},
+		{"20/09/2018", "", true},
+		{"", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.date, func(t *testing.T) {
+			ms, err := serviceDateMillis(test.date)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, ms)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+)
+
+// clearScreen - moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+func init() {
+	arrivalsCmd.Flags().String("stop", "", "stop id")
+	arrivalsCmd.Flags().Int("minutes-before", 5, "include arrivals this many minutes in the past")
+	arrivalsCmd.Flags().Int("minutes-after", 35, "include arrivals this many minutes in the future")
+	arrivalsCmd.Flags().StringSlice("route", nil, "only show these route ids or short names")
+	arrivalsCmd.Flags().Bool("watch", false, "refresh the board until interrupted, as a table unless --output is given")
+	arrivalsCmd.Flags().Duration("interval", 30*time.Second, "refresh interval of --watch")
+	rootCmd.AddCommand(arrivalsCmd)
+}
+
+var arrivalsCmd = &cobra.Command{
+	Use:   "arrivals",
+	Short: "arrivals board for a stop",
+	Long:  "show upcoming arrivals at a stop with scheduled and predicted times",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		id, err := cmd.Flags().GetString("stop")
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			return fmt.Errorf("--stop is required")
+		}
+		before, err := cmd.Flags().GetInt("minutes-before")
+		if err != nil {
+			return err
+		}
+		after, err := cmd.Flags().GetInt("minutes-after")
+		if err != nil {
+			return err
+		}
+		routes, err := cmd.Flags().GetStringSlice("route")
+		if err != nil {
+			return err
+		}
+		params := map[string]string{
+			"minutesBefore": strconv.Itoa(before),
+			"minutesAfter":  strconv.Itoa(after),
+		}
+		show := func() error {
+			return Arrivals(cmd, client, id, params, routes)
+		}
+
+		watch, err := cmd.Flags().GetBool("watch")
+		if err != nil {
+			return err
+		}
+		if !watch {
+			return show()
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		watchTable(cmd)
+		return watchEvery(interval, func() error {
+			if outputFormat == formatTable {
+				fmt.Fprint(cmd.OutOrStdout(), clearScreen)
+				fmt.Fprintf(cmd.OutOrStdout(), "Stop %s, updated %s\n\n", id, now().Format("15:04:05"))
+			}
+			return show()
+		})
+	},
+}
+
+// Arrivals - print the arrivals at stop id served by any of routes, all when
+// empty, soonest first
+func Arrivals(cmd *cobra.Command, client oba.Client, id string, params map[string]string, routes []string) error {
+	sad, err := client.ArrivalsAndDeparturesForStop(id, params)
+	if err != nil {
+		return err
+	}
+	if sad == nil {
+		return fmt.Errorf("no arrivals for stop %s", id)
+	}
+	ads := filterArrivals(sad.ArrivalsAndDepartures, routes)
+	sort.SliceStable(ads, func(i, j int) bool {
+		return arrivalTime(ads[i]) < arrivalTime(ads[j])
+	})
+	return output(cmd, ads)
+}
+
+func filterArrivals(ads []oba.ArrivalAndDeparture, routes []string) []oba.ArrivalAndDeparture {
+	if len(routes) == 0 {
+		return ads
+	}
+	want := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		want[r] = true
+	}
+	var kept []oba.ArrivalAndDeparture
+	for _, a := range ads {
+		if want[a.RouteID] || want[a.RouteShortName] {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// watchTable - watch in the table format unless --output is given, other
+// formats append each refresh to the output
+func watchTable(cmd *cobra.Command) {
+	if !cmd.Flags().Changed("output") {
+		outputFormat = formatTable
+	}
+}
+
+// watchEvery - call fn now and every interval until interrupted, errors are
+// logged but do not stop watching
+func watchEvery(interval time.Duration, fn func() error) error {
+	if interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt)
+	defer signal.Stop(stop)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := fn(); err != nil {
+			logger.Log(oba.LevelError, "refresh failed", oba.F("error", err))
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func testArrivals() []oba.ArrivalAndDeparture {
+	return []oba.ArrivalAndDeparture{
+		{TripID: "t1", RouteID: "1_100224", RouteShortName: "44", ScheduledArrivalTime: 3000},
+		{TripID: "t2", RouteID: "1_100228", RouteShortName: "48", ScheduledArrivalTime: 1000, PredictedArrivalTime: 4000},
+		{TripID: "t3", RouteID: "1_100224", RouteShortName: "44", ScheduledArrivalTime: 2000},
+		{TripID: "t4", RouteID: "40_100479", RouteShortName: "Link", ScheduledArrivalTime: 2000},
+	}
+}
+
+func tripIDs(ads []oba.ArrivalAndDeparture) []string {
+	var ids []string
+	for _, a := range ads {
+		ids = append(ids, a.TripID)
+	}
+	return ids
+}
+
+func TestFilterArrivals(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []string
+		want   []string
+	}{
+		{"all routes", nil, []string{"t1", "t2", "t3", "t4"}},
+		{"route id", []string{"1_100228"}, []string{"t2"}},
+		{"short name", []string{"44"}, []string{"t1", "t3"}},
+		{"id and short name", []string{"Link", "1_100228"}, []string{"t2", "t4"}},
+		{"no match", []string{"1_1"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, tripIDs(filterArrivals(testArrivals(), test.routes)))
+		})
+	}
+}
+
+func TestArrivals(t *testing.T) {
+	client := &fakeClient{arrivals: map[string]*oba.StopWithArrivalsAndDepartures{
+		"1_75403": {StopID: "1_75403", ArrivalsAndDepartures: testArrivals()},
+		"1_1":     nil,
+	}}
+	tests := []struct {
+		name   string
+		stop   string
+		routes []string
+		want   []string
+		err    string
+	}{
+		{"soonest first, predicted over scheduled", "1_75403", nil, []string{"t3", "t4", "t1", "t2"}, ""},
+		{"filtered", "1_75403", []string{"44"}, []string{"t3", "t1"}, ""},
+		{"no arrivals entry", "1_1", nil, nil, "no arrivals for stop 1_1"},
+		{"request failed", "1_2", nil, nil, "not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ads []oba.ArrivalAndDeparture
+			err := runOutput(t, &ads, func(cmd *cobra.Command) error {
+				return Arrivals(cmd, client, test.stop, nil, test.routes)
+			})
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Equal(t, test.err, err.Error())
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, tripIDs(ads))
+		})
+	}
+}
@@ -1,9 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
-	"github.com/Setheck/oba"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +13,7 @@ var blockCmd = &cobra.Command{
 	Short: "retrieve blocks",
 	Long:  "get some blocks",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := oba.NewDefaultClientS(baseUrl, apiKey)
+		client := newClient()
 		id, err := cmd.Flags().GetString("id")
 		if err != nil {
 			return err
@@ -25,7 +22,6 @@ var blockCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		fmt.Println(block)
-		return nil
+		return output(cmd, block)
 	},
 }
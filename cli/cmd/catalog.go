@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Setheck/oba"
+)
+
+// catalog kinds
+const (
+	kindAgency = "agency"
+	kindRoute  = "route"
+	kindStop   = "stop"
+)
+
+// catalogWorkers - how many routes have their stops fetched at once
+const catalogWorkers = 4
+
+// Catalog - the agency, route and stop ids of a server cached for shell
+// completion
+type Catalog struct {
+	BaseURL  string
+	Updated  time.Time
+	Agencies []CatalogEntry
+	Routes   []CatalogEntry
+	Stops    []CatalogEntry
+}
+
+// CatalogEntry - an id and the name shown beside it when completing
+type CatalogEntry struct {
+	ID          string
+	Description string `json:",omitempty"`
+}
+
+// BuildCatalog - the catalog of agencies, all with coverage when empty. Routes
+// and stops that fail to load are logged and left out.
+func BuildCatalog(client oba.Client, agencies []string) (*Catalog, error) {
+	awcs, err := client.AgenciesWithCoverage()
+	if err != nil {
+		return nil, err
+	}
+	want := make(map[string]bool, len(agencies))
+	for _, a := range agencies {
+		want[a] = true
+	}
+
+	c := &Catalog{BaseURL: baseUrl, Updated: now()}
+	routes, stops := make(map[string]string), make(map[string]string)
+	var routeIDs, stopIDs []string
+	for _, awc := range awcs {
+		a := awc.Agency
+		if len(want) > 0 && !want[a.ID] {
+			continue
+		}
+		c.Agencies = append(c.Agencies, CatalogEntry{ID: a.ID, Description: a.Name})
+
+		rs, err := agencyRoutes(client, a.ID)
+		if err != nil {
+			logger.Log(oba.LevelWarn, "routes failed", oba.F("agency", a.ID), oba.F("error", err))
+		}
+		for _, r := range rs {
+			if _, ok := routes[r.ID]; ok {
+				continue
+			}
+			routes[r.ID] = routeDescription(r)
+			routeIDs = append(routeIDs, r.ID)
+		}
+
+		ids, err := client.StopIDsForAgency(a.ID)
+		if err != nil {
+			logger.Log(oba.LevelWarn, "stop ids failed", oba.F("agency", a.ID), oba.F("error", err))
+		}
+		stopIDs = append(stopIDs, ids...)
+	}
+	routeStopNames(client, routeIDs, stops)
+	// stops not served by a route keep their id without a name
+	for _, id := range stopIDs {
+		if _, ok := stops[id]; !ok {
+			stops[id] = ""
+		}
+	}
+	for id, name := range routes {
+		c.Routes = append(c.Routes, CatalogEntry{ID: id, Description: name})
+	}
+	for id, name := range stops {
+		c.Stops = append(c.Stops, CatalogEntry{ID: id, Description: name})
+	}
+	for _, es := range [][]CatalogEntry{c.Agencies, c.Routes, c.Stops} {
+		sort.Slice(es, func(i, j int) bool { return es[i].ID < es[j].ID })
+	}
+	return c, nil
+}
+
+// routeStopNames - add the stops of routes with their names to stops, the
+// routes are fetched catalogWorkers at a time. Routes that fail or have no
+// entry are logged and left out.
+func routeStopNames(client oba.Client, routes []string, stops map[string]string) {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan string)
+	for w := 0; w < catalogWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range jobs {
+				sfr, err := client.StopsForRoute(id)
+				if err != nil {
+					logger.Log(oba.LevelWarn, "stops failed", oba.F("route", id), oba.F("error", err))
+					continue
+				}
+				if sfr == nil {
+					logger.Log(oba.LevelWarn, "no stops", oba.F("route", id))
+					continue
+				}
+				mu.Lock()
+				for _, s := range sfr.Stops {
+					stops[s.ID] = s.Name
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range routes {
+		jobs <- id
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// agencyRoutes - the routes of an agency, only their ids when the full routes
+// are not available
+func agencyRoutes(client oba.Client, id string) ([]oba.Route, error) {
+	routes, err := client.RoutesForAgency(id)
+	if err == nil {
+		return routes, nil
+	}
+	ids, idErr := client.RouteIdsForAgency(id)
+	if idErr != nil {
+		return nil, err
+	}
+	routes = make([]oba.Route, 0, len(ids))
+	for _, rid := range ids {
+		routes = append(routes, oba.Route{ID: rid})
+	}
+	return routes, nil
+}
+
+func routeDescription(r oba.Route) string {
+	var parts []string
+	for _, p := range []string{r.ShortName, r.LongName} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	if len(parts) == 1 && r.Description != "" {
+		parts = append(parts, r.Description)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Entries - the entries of kind whose id or description starts with prefix
+func (c *Catalog) Entries(kind, prefix string) []CatalogEntry {
+	var es []CatalogEntry
+	switch kind {
+	case kindAgency:
+		es = c.Agencies
+	case kindRoute:
+		es = c.Routes
+	case kindStop:
+		es = c.Stops
+	}
+	prefix = strings.ToLower(prefix)
+	var matched []CatalogEntry
+	for _, e := range es {
+		if strings.HasPrefix(strings.ToLower(e.ID), prefix) ||
+			(prefix != "" && strings.HasPrefix(strings.ToLower(e.Description), prefix)) {
+			matched = append(matched, e)
+		}
+	}
+	return matched
+}
+
+// catalogPath - the cache file of the catalog of the current profile
+func catalogPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "oba", "catalog-"+profile+".json"), nil
+}
+
+// loadCatalog - the cached catalog, nil when there is none
+func loadCatalog() (*Catalog, error) {
+	path, err := catalogPath()
+	if err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var c Catalog
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// saveCatalog - replace the cached catalog through a temporary file
+func saveCatalog(c *Catalog) (string, error) {
+	path, err := catalogPath()
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return "", err
+	}
+	return path, os.Rename(tmp, path)
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog_Entries(t *testing.T) {
+	c := &Catalog{
+		Agencies: []CatalogEntry{{ID: "1", Description: "Metro Transit"}, {ID: "40", Description: "Sound Transit"}},
+		Routes: []CatalogEntry{
+			{ID: "1_100224", Description: "44 Ballard - Montlake"},
+			{ID: "1_100228", Description: "48 Mount Baker - University District"},
+			{ID: "40_100479", Description: "Link light rail"},
+		},
+		Stops: []CatalogEntry{{ID: "1_75403", Description: "Stevens Way & Benton Ln"}, {ID: "1_75414"}},
+	}
+	tests := []struct {
+		name   string
+		kind   string
+		prefix string
+		want   []string
+	}{
+		{"all of a kind", kindAgency, "", []string{"1", "40"}},
+		{"id prefix", kindRoute, "1_", []string{"1_100224", "1_100228"}},
+		{"description prefix", kindRoute, "44", []string{"1_100224"}},
+		{"description ignores case", kindRoute, "link", []string{"40_100479"}},
+		{"id or description", kindAgency, "s", []string{"40"}},
+		{"not a word prefix", kindRoute, "Ballard", nil},
+		{"stops without a description", kindStop, "1_754", []string{"1_75403", "1_75414"}},
+		{"unknown kind", "vehicle", "", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var ids []string
+			for _, e := range c.Entries(test.kind, test.prefix) {
+				ids = append(ids, e.ID)
+			}
+			assert.Equal(t, test.want, ids)
+		})
+	}
+}
+
+func TestAgencyRoutes(t *testing.T) {
+	client := &fakeClient{
+		routes:   map[string][]oba.Route{"1": {{ID: "1_100224", ShortName: "44"}}},
+		routeIDs: map[string][]string{"1": {"1_100224", "1_100228"}, "40": {"40_100479"}},
+	}
+	tests := []struct {
+		name   string
+		agency string
+		want   []oba.Route
+		err    string
+	}{
+		{"full routes", "1", []oba.Route{{ID: "1_100224", ShortName: "44"}}, ""},
+		{"ids when routes fail", "40", []oba.Route{{ID: "40_100479"}}, ""},
+		{"error of the routes request", "3", nil, "not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rs, err := agencyRoutes(client, test.agency)
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Equal(t, test.err, err.Error())
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, rs)
+		})
+	}
+}
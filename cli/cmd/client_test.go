@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClient - answers the requests the commands make from fixed results,
+// other requests panic on the nil Client
+type fakeClient struct {
+	oba.Client
+	arrivals map[string]*oba.StopWithArrivalsAndDepartures
+	stops    []oba.Stop
+	vehicles map[string][]oba.VehicleStatus
+	routes   map[string][]oba.Route
+	routeIDs map[string][]string
+	sfrs     map[string]*oba.StopsForRoute
+}
+
+var errNotFound = errors.New("not found")
+
+func (c *fakeClient) ArrivalsAndDeparturesForStop(id string, params map[string]string) (*oba.StopWithArrivalsAndDepartures, error) {
+	sad, ok := c.arrivals[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return sad, nil
+}
+
+func (c *fakeClient) StopsForLocation(params map[string]string) ([]oba.Stop, error) {
+	return c.stops, nil
+}
+
+func (c *fakeClient) VehiclesForAgency(id string) ([]oba.VehicleStatus, error) {
+	vs, ok := c.vehicles[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return vs, nil
+}
+
+func (c *fakeClient) RoutesForAgency(id string) ([]oba.Route, error) {
+	rs, ok := c.routes[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return rs, nil
+}
+
+func (c *fakeClient) RouteIdsForAgency(id string) ([]string, error) {
+	ids, ok := c.routeIDs[id]
+	if !ok {
+		return nil, errors.New("no route ids")
+	}
+	return ids, nil
+}
+
+func (c *fakeClient) StopsForRoute(id string) (*oba.StopsForRoute, error) {
+	sfr, ok := c.sfrs[id]
+	if !ok {
+		return nil, errNotFound
+	}
+	return sfr, nil
+}
+
+// runOutput - the json fn outputs, decoded into v
+func runOutput(t *testing.T, v interface{}, fn func(cmd *cobra.Command) error) error {
+	t.Helper()
+	defer func(f string, fields []string, pretty bool) {
+		outputFormat, outputFields, outputPretty = f, fields, pretty
+	}(outputFormat, outputFields, outputPretty)
+	outputFormat, outputFields, outputPretty = formatJSON, nil, false
+
+	var buf bytes.Buffer
+	cmd := &cobra.Command{}
+	cmd.SetOutput(&buf)
+	if err := fn(cmd); err != nil {
+		return err
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), v), buf.String())
+	return nil
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Setheck/oba"
+)
+
+// now - the time used for relative columns such as minutes away
+var now = time.Now
+
+func init() {
+	registerColumns(oba.Agency{},
+		Column{"id", func(v interface{}) string { return v.(oba.Agency).ID }},
+		Column{"name", func(v interface{}) string { return v.(oba.Agency).Name }},
+		Column{"timezone", func(v interface{}) string { return v.(oba.Agency).TimeZone }},
+		Column{"phone", func(v interface{}) string { return v.(oba.Agency).Phone }},
+		Column{"url", func(v interface{}) string { return v.(oba.Agency).URL }},
+	)
+	registerColumns(oba.AgencyWithCoverage{},
+		Column{"id", func(v interface{}) string { return v.(oba.AgencyWithCoverage).Agency.ID }},
+		Column{"name", func(v interface{}) string { return v.(oba.AgencyWithCoverage).Agency.Name }},
+		Column{"lat", func(v interface{}) string { return coord(v.(oba.AgencyWithCoverage).Lat) }},
+		Column{"lon", func(v interface{}) string { return coord(v.(oba.AgencyWithCoverage).Lon) }},
+	)
+	registerColumns(oba.Route{},
+		Column{"id", func(v interface{}) string { return v.(oba.Route).ID }},
+		Column{"short_name", func(v interface{}) string { return v.(oba.Route).ShortName }},
+		Column{"long_name", func(v interface{}) string { return v.(oba.Route).LongName }},
+		Column{"description", func(v interface{}) string { return v.(oba.Route).Description }},
+		Column{"agency", func(v interface{}) string { return v.(oba.Route).Agency.Name }},
+		Column{"type", func(v interface{}) string { return strconv.Itoa(v.(oba.Route).Type) }},
+		Column{"color", func(v interface{}) string { return v.(oba.Route).Color }},
+		Column{"text_color", func(v interface{}) string { return v.(oba.Route).TextColor }},
+	)
+	registerColumns(oba.Stop{},
+		Column{"id", func(v interface{}) string { return v.(oba.Stop).ID }},
+		Column{"code", func(v interface{}) string { return v.(oba.Stop).Code }},
+		Column{"name", func(v interface{}) string { return v.(oba.Stop).Name }},
+		Column{"direction", func(v interface{}) string { return v.(oba.Stop).Direction }},
+		Column{"lat", func(v interface{}) string { return coord(v.(oba.Stop).Lat) }},
+		Column{"lon", func(v interface{}) string { return coord(v.(oba.Stop).Lon) }},
+		Column{"routes", func(v interface{}) string { return routeNames(v.(oba.Stop).Routes) }},
+	)
+	registerColumns(oba.Trip{},
+		Column{"id", func(v interface{}) string { return v.(oba.Trip).ID }},
+		Column{"route", func(v interface{}) string { return v.(oba.Trip).RouteID }},
+		Column{"route_short_name", func(v interface{}) string { return v.(oba.Trip).RouteShortName }},
+		Column{"headsign", func(v interface{}) string { return v.(oba.Trip).TripHeadsign }},
+		Column{"direction", func(v interface{}) string { return v.(oba.Trip).DirectionID }},
+		Column{"block", func(v interface{}) string { return v.(oba.Trip).BlockID }},
+		Column{"service", func(v interface{}) string { return v.(oba.Trip).ServiceID }},
+	)
+	registerColumns(oba.ArrivalAndDeparture{},
+		Column{"route", func(v interface{}) string { return arrivalRoute(v.(oba.ArrivalAndDeparture)) }},
+		Column{"headsign", func(v interface{}) string { return v.(oba.ArrivalAndDeparture).TripHeadSign }},
+		Column{"scheduled", func(v interface{}) string { return clock(v.(oba.ArrivalAndDeparture).ScheduledArrivalTime) }},
+		Column{"predicted", func(v interface{}) string { return clock(v.(oba.ArrivalAndDeparture).PredictedArrivalTime) }},
+		Column{"minutes", func(v interface{}) string { return minutesAway(arrivalTime(v.(oba.ArrivalAndDeparture))) }},
+		Column{"realtime", func(v interface{}) string { return yesNo(realtime(v.(oba.ArrivalAndDeparture))) }},
+		Column{"trip", func(v interface{}) string { return v.(oba.ArrivalAndDeparture).TripID }},
+		Column{"vehicle", func(v interface{}) string { return v.(oba.ArrivalAndDeparture).VehicleID }},
+		Column{"stop", func(v interface{}) string { return v.(oba.ArrivalAndDeparture).StopID }},
+	)
+	registerColumns(oba.VehicleStatus{},
+		Column{"vehicle", func(v interface{}) string { return v.(oba.VehicleStatus).VehicleID }},
+		Column{"route", func(v interface{}) string { return v.(oba.VehicleStatus).Trip.RouteID }},
+		Column{"trip", func(v interface{}) string { return v.(oba.VehicleStatus).Trip.ID }},
+		Column{"headsign", func(v interface{}) string { return v.(oba.VehicleStatus).Trip.TripHeadsign }},
+		Column{"lat", func(v interface{}) string { return coord(v.(oba.VehicleStatus).Location.Lat) }},
+		Column{"lon", func(v interface{}) string { return coord(v.(oba.VehicleStatus).Location.Lon) }},
+		Column{"deviation", func(v interface{}) string { return deviation(v.(oba.VehicleStatus).TripStatus.ScheduleDeviation) }},
+		Column{"updated", func(v interface{}) string { return age(v.(oba.VehicleStatus).LastUpdateTime) }},
+		Column{"phase", func(v interface{}) string { return v.(oba.VehicleStatus).Phase }},
+	)
+	registerColumns(oba.TripDetails{},
+		Column{"trip", func(v interface{}) string { return v.(oba.TripDetails).Trip.ID }},
+		Column{"route", func(v interface{}) string { return v.(oba.TripDetails).Trip.RouteID }},
+		Column{"headsign", func(v interface{}) string { return v.(oba.TripDetails).Trip.TripHeadsign }},
+		Column{"service_date", func(v interface{}) string { return day(v.(oba.TripDetails).ServiceDate) }},
+		Column{"status", func(v interface{}) string { return tripStatus(v.(oba.TripDetails)) }},
+		Column{"vehicle", func(v interface{}) string { return liveStatus(v).VehicleID }},
+		Column{"next_stop", func(v interface{}) string { return liveStatus(v).NextStop.Name }},
+		Column{"next_stop_in", func(v interface{}) string { return nextStopIn(liveStatus(v)) }},
+		Column{"deviation", func(v interface{}) string { return liveDeviation(liveStatus(v)) }},
+		Column{"updated", func(v interface{}) string { return age(liveStatus(v).LastUpdateTime) }},
+	)
+	registerColumns(oba.Situation{},
+		Column{"id", func(v interface{}) string { return v.(oba.Situation).ID }},
+		Column{"reason", func(v interface{}) string { return v.(oba.Situation).EnvironmentReason }},
+		Column{"summary", func(v interface{}) string { return strings.Join(v.(oba.Situation).Summary, " ") }},
+		Column{"created", func(v interface{}) string { return v.(oba.Situation).CreationTime }},
+	)
+	registerColumns("",
+		Column{"id", func(v interface{}) string { return v.(string) }},
+	)
+}
+
+func coord(f float64) string {
+	return strconv.FormatFloat(f, 'f', 6, 64)
+}
+
+func routeNames(rs []oba.Route) string {
+	names := make([]string, 0, len(rs))
+	for _, r := range rs {
+		if r.ShortName != "" {
+			names = append(names, r.ShortName)
+		} else {
+			names = append(names, r.ID)
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func arrivalRoute(a oba.ArrivalAndDeparture) string {
+	if a.RouteShortName != "" {
+		return a.RouteShortName
+	}
+	return a.RouteID
+}
+
+// realtime - the arrival has a prediction from a vehicle
+func realtime(a oba.ArrivalAndDeparture) bool {
+	return a.PredictedArrivalTime != 0 || a.PredictedDepartureTime != 0
+}
+
+// arrivalTime - the predicted arrival, or the scheduled one without a
+// prediction
+func arrivalTime(a oba.ArrivalAndDeparture) int {
+	if a.PredictedArrivalTime != 0 {
+		return a.PredictedArrivalTime
+	}
+	return a.ScheduledArrivalTime
+}
+
+// minutesAway - whole minutes from now until ms, "now" within the minute
+func minutesAway(ms int) string {
+	if ms == 0 {
+		return ""
+	}
+	m := int(oba.MillisToTime(ms).Sub(now()).Minutes())
+	if m == 0 {
+		return "now"
+	}
+	return strconv.Itoa(m)
+}
+
+// deviation - a schedule deviation in seconds as minutes early or late
+func deviation(secs int) string {
+	switch {
+	case secs >= 60:
+		return fmt.Sprint(secs/60, "m late")
+	case secs <= -60:
+		return fmt.Sprint(-secs/60, "m early")
+	default:
+		return "on time"
+	}
+}
+
+// age - how long ago ms was
+func age(ms int) string {
+	if ms == 0 {
+		return ""
+	}
+	return now().Sub(oba.MillisToTime(ms)).Truncate(time.Second).String() + " ago"
+}
+
+func day(ms int) string {
+	if ms == 0 {
+		return ""
+	}
+	return oba.MillisToTime(ms).Local().Format("2006-01-02")
+}
+
+// tripStatus - the status text of trip details, the phase of a realtime status
+func tripStatus(td oba.TripDetails) string {
+	if td.TripStatus != nil {
+		return td.TripStatus.Phase
+	}
+	return td.Status
+}
+
+// liveStatus - the realtime status of the trip details v, zero without one
+func liveStatus(v interface{}) oba.TripStatus {
+	if s := v.(oba.TripDetails).TripStatus; s != nil {
+		return *s
+	}
+	return oba.TripStatus{}
+}
+
+func nextStopIn(s oba.TripStatus) string {
+	if s.NextStop.ID == "" {
+		return ""
+	}
+	return (time.Duration(s.NextStopTimeOffset) * time.Second).String()
+}
+
+func liveDeviation(s oba.TripStatus) string {
+	if s.LastUpdateTime == 0 {
+		return ""
+	}
+	return deviation(s.ScheduleDeviation)
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// bashCompleteIDs - completes the ids of the kind given as the argument from
+// the catalog. With several candidates each shows its description, like
+// "1_100224  (44 Ballard - Montlake)", a single one completes to the bare id.
+const bashCompleteIDs = `__oba_complete_ids()
+{
+    local IFS=$'\n' line i
+    COMPREPLY=()
+    for line in $("${words[0]}" __ids "$1" "${cur}" 2>/dev/null); do
+        COMPREPLY+=("${line}")
+    done
+    if [[ ${#COMPREPLY[@]} -eq 1 ]]; then
+        COMPREPLY=("${COMPREPLY[0]%%$'\t'*}")
+        return
+    fi
+    for i in "${!COMPREPLY[@]}"; do
+        line=${COMPREPLY[i]}
+        if [[ ${line} == *$'\t'* ]]; then
+            COMPREPLY[i]="${line%%$'\t'*}  (${line#*$'\t'})"
+        fi
+    done
+}
+`
+
+func init() {
+	completionCmd.AddCommand(completionBashCmd, completionZshCmd, completionFishCmd, completionRefreshCmd)
+	completionRefreshCmd.Flags().StringSlice("agency", nil, "only catalog these agencies, all with coverage when empty")
+	rootCmd.AddCommand(completionCmd, idsCmd)
+}
+
+var completionCmd = &cobra.Command{
+	Use:   "completion",
+	Short: "shell completion",
+	Long: "generate shell completion scripts. Agency, route and stop ids complete from a catalog " +
+		"cached per profile, fill it with 'oba completion refresh'",
+}
+
+var completionBashCmd = &cobra.Command{
+	Use:   "bash",
+	Short: "bash completion, load with: source <(oba completion bash)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return bashCompletion(cmd.OutOrStdout())
+	},
+}
+
+var completionZshCmd = &cobra.Command{
+	Use:   "zsh",
+	Short: "zsh completion, load with: source <(oba completion zsh)",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		w := cmd.OutOrStdout()
+		fmt.Fprintf(w, "#compdef %s\n\nautoload -U +X bashcompinit && bashcompinit\n\n", rootCmd.Name())
+		return bashCompletion(w)
+	},
+}
+
+var completionFishCmd = &cobra.Command{
+	Use:   "fish",
+	Short: "fish completion, load with: oba completion fish | source",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return fishCompletion(cmd.OutOrStdout())
+	},
+}
+
+var completionRefreshCmd = &cobra.Command{
+	Use:   "refresh",
+	Short: "cache the agency, route and stop ids of the current profile",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		agencies, err := cmd.Flags().GetStringSlice("agency")
+		if err != nil {
+			return err
+		}
+		c, err := BuildCatalog(client, agencies)
+		if err != nil {
+			return err
+		}
+		path, err := saveCatalog(c)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "cached %d agencies, %d routes and %d stops in %s\n",
+			len(c.Agencies), len(c.Routes), len(c.Stops), path)
+		return nil
+	},
+}
+
+// idsCmd - the completion candidates called by the scripts, one "id\tname"
+// per line
+var idsCmd = &cobra.Command{
+	Use:    "__ids <agency|route|stop> [prefix]",
+	Hidden: true,
+	Args:   cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := loadCatalog()
+		if err != nil || c == nil {
+			return err
+		}
+		prefix := ""
+		if len(args) > 1 {
+			prefix = args[1]
+		}
+		for _, e := range c.Entries(args[0], prefix) {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\n", e.ID, e.Description)
+		}
+		return nil
+	},
+}
+
+// idKind - the catalog kind completing flag name of c, empty for none
+func idKind(c *cobra.Command, name string) string {
+	switch name {
+	case "aid", "agency":
+		return kindAgency
+	case "stop":
+		return kindStop
+	case "route":
+		return kindRoute
+	case "id":
+		switch c.Name() {
+		case kindAgency, kindRoute, kindStop:
+			return c.Name()
+		}
+	}
+	return ""
+}
+
+// markIDFlags - annotate the id flags of c and its sub commands with the
+// bash completion function
+func markIDFlags(c *cobra.Command) {
+	for _, sub := range c.Commands() {
+		markIDFlags(sub)
+	}
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if kind := idKind(c, f.Name); kind != "" {
+			cobra.MarkFlagCustom(c.Flags(), f.Name, "__oba_complete_ids "+kind)
+		}
+	})
+}
+
+func bashCompletion(w io.Writer) error {
+	markIDFlags(rootCmd)
+	rootCmd.BashCompletionFunction = bashCompleteIDs
+	return rootCmd.GenBashCompletion(w)
+}
+
+// fishCompletion - the sub commands and flags of every command, fish shows the
+// catalog names beside the ids itself
+func fishCompletion(w io.Writer) error {
+	var buf bytes.Buffer
+	name := rootCmd.Name()
+	fmt.Fprintf(&buf, "function __%s_ids\n    %s __ids $argv[1] (commandline -ct) 2>/dev/null\nend\n\n", name, name)
+	fmt.Fprintf(&buf, "complete -c %s -f\n", name)
+	rootCmd.PersistentFlags().VisitAll(func(f *pflag.Flag) {
+		fishFlag(&buf, rootCmd, "", f)
+	})
+
+	var walk func(c *cobra.Command, cond string)
+	walk = func(c *cobra.Command, cond string) {
+		if c != rootCmd {
+			c.LocalNonPersistentFlags().VisitAll(func(f *pflag.Flag) {
+				fishFlag(&buf, c, cond, f)
+			})
+		}
+		for _, sub := range c.Commands() {
+			if !sub.IsAvailableCommand() {
+				continue
+			}
+			fmt.Fprintf(&buf, "complete -c %s -n '%s' -a %s -d %s\n", name, cond, sub.Name(), fishQuote(sub.Short))
+			subCond := "__fish_seen_subcommand_from " + sub.Name()
+			if c != rootCmd {
+				subCond = cond + "; and " + subCond
+			}
+			walk(sub, subCond)
+		}
+	}
+	walk(rootCmd, "__fish_use_subcommand")
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func fishFlag(w io.Writer, c *cobra.Command, cond string, f *pflag.Flag) {
+	line := "complete -c " + rootCmd.Name()
+	if cond != "" {
+		line += " -n '" + cond + "'"
+	}
+	line += " -l " + f.Name
+	if f.Shorthand != "" {
+		line += " -s " + f.Shorthand
+	}
+	if kind := idKind(c, f.Name); kind != "" {
+		line += fmt.Sprintf(" -x -a '(__%s_ids %s)'", rootCmd.Name(), kind)
+	} else if f.Value.Type() != "bool" {
+		line += " -x"
+	}
+	fmt.Fprintln(w, line+" -d "+fishQuote(f.Usage))
+}
+
+func fishQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `\'`, -1) + "'"
+}
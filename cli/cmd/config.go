@@ -0,0 +1,403 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// config keys
+const (
+	keyBaseURL  = "baseUrl"
+	keyAPIKey   = "apiKey"
+	keyProfile  = "profile"
+	keyProfiles = "profiles"
+)
+
+// defaultProfile - the profile held by the top level baseUrl and apiKey
+const defaultProfile = "default"
+
+// environment overrides
+const (
+	envBaseURL = "OBA_BASE_URL"
+	envAPIKey  = "OBA_API_KEY"
+	envProfile = "OBA_PROFILE"
+)
+
+// the settings in use, resolved by initConfig
+var baseUrl string
+var apiKey string
+var profile string
+
+// configErr - why the settings could not be resolved, reported by every
+// command but config
+var configErr error
+
+var flagBaseURL string
+var flagAPIKey string
+var flagProfile string
+
+// ConfigProfile - a named server and key of the config file
+type ConfigProfile struct {
+	Name    string
+	BaseURL string
+	// APIKey - the key with all but the last four characters masked
+	APIKey  string
+	Current bool
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&flagBaseURL, "base-url", "", "api base url, overrides the profile and "+envBaseURL)
+	rootCmd.PersistentFlags().StringVar(&flagAPIKey, "key", "", "api key, overrides the profile and "+envAPIKey)
+	rootCmd.PersistentFlags().StringVar(&flagProfile, "profile", "", "config profile to use, overrides "+envProfile)
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		for c := cmd; c != nil; c = c.Parent() {
+			if c == configCmd {
+				return nil
+			}
+		}
+		return configErr
+	}
+
+	configInitCmd.Flags().Bool("force", false, "overwrite an existing config file")
+	configCmd.AddCommand(configInitCmd, configSetCmd, configGetCmd, configListCmd)
+	rootCmd.AddCommand(configCmd)
+
+	registerColumns(ConfigProfile{},
+		Column{"name", func(v interface{}) string { return v.(ConfigProfile).Name }},
+		Column{"base_url", func(v interface{}) string { return v.(ConfigProfile).BaseURL }},
+		Column{"api_key", func(v interface{}) string { return v.(ConfigProfile).APIKey }},
+		Column{"current", func(v interface{}) string { return yesNo(v.(ConfigProfile).Current) }},
+	)
+}
+
+// initConfig - resolve the settings from the flags, then the environment,
+// then the selected profile of the config file
+func initConfig() {
+	viper.SetConfigName("config")
+	viper.AddConfigPath("$HOME/.obacli")
+	viper.AddConfigPath(".")
+	if err := viper.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			logger.Log(oba.LevelInfo, "no config file", oba.F("error", err))
+		} else {
+			logger.Log(oba.LevelWarn, "unable to read config file", oba.F("error", err))
+		}
+	}
+
+	profile = firstOf(flagProfile, os.Getenv(envProfile), viper.GetString(keyProfile), defaultProfile)
+	prefix := ""
+	if profile != defaultProfile {
+		prefix = keyProfiles + "." + profile + "."
+		if !viper.IsSet(keyProfiles + "." + profile) {
+			configErr = fmt.Errorf("unknown profile %q, add it with 'oba config set --profile %s'", profile, profile)
+		}
+	}
+	baseUrl = firstOf(flagBaseURL, os.Getenv(envBaseURL), viper.GetString(prefix+keyBaseURL))
+	apiKey = firstOf(flagAPIKey, os.Getenv(envAPIKey), viper.GetString(prefix+keyAPIKey))
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "manage the config file",
+	Long: "manage the profiles of the config file, each a base url and api key. " +
+		"The profile is chosen by --profile, " + envProfile + " or the profile key of the file",
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "create the config file",
+	Long:  "create the config file with the --base-url and --key, or the public test server",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			return err
+		}
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); err == nil && !force {
+			return fmt.Errorf("%s exists, use --force to replace it", path)
+		}
+		settings := yaml.MapSlice{
+			{Key: keyBaseURL, Value: firstOf(baseUrl, "http://api.pugetsound.onebusaway.org/api/where/")},
+			{Key: keyAPIKey, Value: firstOf(apiKey, "TEST")},
+		}
+		config := settings
+		if profile != defaultProfile {
+			config = yaml.MapSlice{
+				{Key: keyProfile, Value: profile},
+				{Key: keyProfiles, Value: yaml.MapSlice{{Key: profile, Value: settings}}},
+			}
+		}
+		if err := writeConfig(path, config); err != nil {
+			return err
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "wrote", path)
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "set a config value",
+	Long: "set " + keyBaseURL + " or " + keyAPIKey + " of the --profile, creating it, or " +
+		keyProfile + " to choose the profile used by default",
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := configKey(args[0])
+		if err != nil {
+			return err
+		}
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		config, err := readConfig(path)
+		if err != nil {
+			return err
+		}
+		value := args[1]
+
+		switch {
+		case key == keyProfile:
+			if _, ok := profileSettings(config, value); !ok && value != defaultProfile {
+				return fmt.Errorf("unknown profile %q", value)
+			}
+			config = mapSet(config, keyProfile, value)
+		case profile == defaultProfile:
+			config = mapSet(config, key, value)
+		default:
+			profiles, _ := mapGet(config, keyProfiles)
+			ps, ok := profiles.(yaml.MapSlice)
+			if profiles != nil && !ok {
+				return fmt.Errorf("%s: %s is not a mapping", path, keyProfiles)
+			}
+			settings, _ := profileSettings(config, profile)
+			ps = mapSet(ps, profile, mapSet(settings, key, value))
+			config = mapSet(config, keyProfiles, ps)
+		}
+		return writeConfig(path, config)
+	},
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "print a setting in use",
+	Long:  "print " + keyBaseURL + ", " + keyAPIKey + " or " + keyProfile + " as resolved from the flags, environment and config file",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key, err := configKey(args[0])
+		if err != nil {
+			return err
+		}
+		value := map[string]string{keyBaseURL: baseUrl, keyAPIKey: apiKey, keyProfile: profile}[key]
+		fmt.Fprintln(cmd.OutOrStdout(), value)
+		return nil
+	},
+}
+
+var configListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "list the profiles of the config file",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := configPath()
+		if err != nil {
+			return err
+		}
+		config, err := readConfig(path)
+		if err != nil {
+			return err
+		}
+		names := []string{defaultProfile}
+		if profiles, ok := mapGet(config, keyProfiles); ok {
+			if ps, ok := profiles.(yaml.MapSlice); ok {
+				var named []string
+				for _, p := range ps {
+					named = append(named, fmt.Sprint(p.Key))
+				}
+				sort.Strings(named)
+				names = append(names, named...)
+			}
+		}
+		var list []ConfigProfile
+		for _, name := range names {
+			settings, _ := profileSettings(config, name)
+			list = append(list, ConfigProfile{
+				Name:    name,
+				BaseURL: mapString(settings, keyBaseURL),
+				APIKey:  maskKey(mapString(settings, keyAPIKey)),
+				Current: strings.EqualFold(name, profile),
+			})
+		}
+		return output(cmd, list)
+	},
+}
+
+// configKey - the canonical name of a settable key
+func configKey(k string) (string, error) {
+	switch strings.ToLower(k) {
+	case "baseurl", "base-url", "base_url":
+		return keyBaseURL, nil
+	case "apikey", "api-key", "api_key", "key":
+		return keyAPIKey, nil
+	case "profile":
+		return keyProfile, nil
+	}
+	return "", fmt.Errorf("unknown key %q, use %s, %s or %s", k, keyBaseURL, keyAPIKey, keyProfile)
+}
+
+// configPath - the config file read at start up, or the default location for
+// a new one
+func configPath() (string, error) {
+	if f := viper.ConfigFileUsed(); f != "" {
+		return f, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".obacli", "config.yaml"), nil
+}
+
+// readConfig - the config file at path in file order, empty when missing
+func readConfig(path string) (yaml.MapSlice, error) {
+	if err := checkYAML(path); err != nil {
+		return nil, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var config yaml.MapSlice
+	if err := yaml.Unmarshal(b, &config); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return config, nil
+}
+
+// writeConfig - replace the config file at path with config through a
+// temporary file, readable only by the user as it holds api keys
+func writeConfig(path string, config yaml.MapSlice) error {
+	if err := checkYAML(path); err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, ".config-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0600); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+func checkYAML(path string) error {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return nil
+	}
+	return fmt.Errorf("%s: only yaml config files can be edited", path)
+}
+
+// profileSettings - the settings of profile name, the top level of config for
+// the default profile
+func profileSettings(config yaml.MapSlice, name string) (yaml.MapSlice, bool) {
+	if name == defaultProfile {
+		return config, true
+	}
+	profiles, ok := mapGet(config, keyProfiles)
+	if !ok {
+		return nil, false
+	}
+	ps, ok := profiles.(yaml.MapSlice)
+	if !ok {
+		return nil, false
+	}
+	settings, ok := mapGet(ps, name)
+	if !ok {
+		return nil, false
+	}
+	s, ok := settings.(yaml.MapSlice)
+	return s, ok
+}
+
+// mapGet - the value of key in m, keys compare case insensitively as viper
+// reads them
+func mapGet(m yaml.MapSlice, key string) (interface{}, bool) {
+	for _, it := range m {
+		if strings.EqualFold(fmt.Sprint(it.Key), key) {
+			return it.Value, true
+		}
+	}
+	return nil, false
+}
+
+// mapSet - m with key set to value, in place when present
+func mapSet(m yaml.MapSlice, key string, value interface{}) yaml.MapSlice {
+	for i, it := range m {
+		if strings.EqualFold(fmt.Sprint(it.Key), key) {
+			m[i].Value = value
+			return m
+		}
+	}
+	return append(m, yaml.MapItem{Key: key, Value: value})
+}
+
+func maskKey(k string) string {
+	if len(k) <= 4 {
+		return strings.Repeat("*", len(k))
+	}
+	return strings.Repeat("*", len(k)-4) + k[len(k)-4:]
+}
+
+// mapString - the value of key in m as text, empty when missing
+func mapString(m yaml.MapSlice, key string) string {
+	if v, ok := mapGet(m, key); ok && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// firstOf - the first non empty value
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
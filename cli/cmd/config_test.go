@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestProfileSettings(t *testing.T) {
+	var config yaml.MapSlice
+	assert.NoError(t, yaml.Unmarshal([]byte(`
+baseUrl: http://api.pugetsound.onebusaway.org
+apiKey: TEST
+profile: tampa
+profiles:
+  Tampa:
+    baseUrl: http://api.tampa.onebusaway.org
+    apiKey: KEY
+  broken: text
+`), &config))
+
+	tests := []struct {
+		name    string
+		baseURL string
+		ok      bool
+	}{
+		{defaultProfile, "http://api.pugetsound.onebusaway.org", true},
+		{"tampa", "http://api.tampa.onebusaway.org", true},
+		{"broken", "", false},
+		{"missing", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, ok := profileSettings(config, test.name)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.baseURL, mapString(s, keyBaseURL))
+		})
+	}
+}
+
+func TestConfigKey(t *testing.T) {
+	for k, want := range map[string]string{
+		"baseurl": keyBaseURL, "base-url": keyBaseURL, "API_KEY": keyAPIKey, "key": keyAPIKey, "profile": keyProfile,
+	} {
+		got, err := configKey(k)
+		assert.NoError(t, err, k)
+		assert.Equal(t, want, got, k)
+	}
+	_, err := configKey("url")
+	assert.Error(t, err)
+}
+
+func TestMaskKey(t *testing.T) {
+	assert.Equal(t, "", maskKey(""))
+	assert.Equal(t, "****", maskKey("TEST"))
+	assert.Equal(t, "******-key", maskKey("a-long-key"))
+}
+
+func TestWriteConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "oba")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "nested", "config.yaml")
+
+	config, err := readConfig(path)
+	assert.NoError(t, err, "a missing file is empty")
+	assert.Empty(t, config)
+
+	config = mapSet(config, keyBaseURL, "http://localhost:8080")
+	config = mapSet(config, "BASEURL", "http://localhost:9090")
+	config = mapSet(config, keyAPIKey, "TEST")
+	if !assert.NoError(t, writeConfig(path, config)) {
+		return
+	}
+	info, err := os.Stat(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+	read, err := readConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, yaml.MapSlice{{Key: keyBaseURL, Value: "http://localhost:9090"}, {Key: keyAPIKey, Value: "TEST"}}, read)
+
+	assert.Error(t, writeConfig(filepath.Join(dir, "config.json"), config), "only yaml is edited")
+}
@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+)
+
+// NearbyStop - a stop near the searched location
+type NearbyStop struct {
+	Stop oba.Stop
+	// Distance - meters from the searched location
+	Distance float64
+	// Arrivals - the next departures, with --arrivals
+	Arrivals []oba.ArrivalAndDeparture `json:",omitempty"`
+}
+
+// NearbyRoute - a route near the searched location
+type NearbyRoute struct {
+	Route oba.Route
+	// Distance - meters from the searched location to NearestStop, zero when
+	// no nearby stop is known to serve the route
+	Distance    float64
+	NearestStop string
+}
+
+func init() {
+	nearbyCmd.Flags().Float64("lat", 0, "latitude")
+	nearbyCmd.Flags().Float64("lon", 0, "longitude")
+	nearbyCmd.Flags().Int("radius", 0, "search radius in meters, the server default when 0")
+	nearbyCmd.Flags().Bool("routes", false, "list routes instead of stops")
+	nearbyCmd.Flags().Int("arrivals", 0, "include the next departures at the closest N stops")
+	rootCmd.AddCommand(nearbyCmd)
+
+	registerColumns(NearbyStop{},
+		Column{"id", func(v interface{}) string { return v.(NearbyStop).Stop.ID }},
+		Column{"name", func(v interface{}) string { return v.(NearbyStop).Stop.Name }},
+		Column{"direction", func(v interface{}) string { return v.(NearbyStop).Stop.Direction }},
+		Column{"distance", func(v interface{}) string { return meters(v.(NearbyStop).Distance) }},
+		Column{"routes", func(v interface{}) string { return routeNames(v.(NearbyStop).Stop.Routes) }},
+		Column{"next", func(v interface{}) string { return nextDepartures(v.(NearbyStop).Arrivals) }},
+	)
+	registerColumns(NearbyRoute{},
+		Column{"id", func(v interface{}) string { return v.(NearbyRoute).Route.ID }},
+		Column{"short_name", func(v interface{}) string { return v.(NearbyRoute).Route.ShortName }},
+		Column{"description", func(v interface{}) string { return v.(NearbyRoute).Route.Description }},
+		Column{"agency", func(v interface{}) string { return v.(NearbyRoute).Route.Agency.Name }},
+		Column{"distance", func(v interface{}) string { return meters(v.(NearbyRoute).Distance) }},
+		Column{"nearest_stop", func(v interface{}) string { return v.(NearbyRoute).NearestStop }},
+	)
+}
+
+var nearbyCmd = &cobra.Command{
+	Use:   "nearby",
+	Short: "stops and routes around a location",
+	Long:  "list the stops, or routes, near a location nearest first",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		lat, err := cmd.Flags().GetFloat64("lat")
+		if err != nil {
+			return err
+		}
+		lon, err := cmd.Flags().GetFloat64("lon")
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("lat") || !cmd.Flags().Changed("lon") {
+			return fmt.Errorf("--lat and --lon are required")
+		}
+		radius, err := cmd.Flags().GetInt("radius")
+		if err != nil {
+			return err
+		}
+		params := oba.LocationParams(lat, lon, "")
+		if radius > 0 {
+			params["radius"] = strconv.Itoa(radius)
+		}
+
+		stops, err := NearbyStops(client, oba.Location{Lat: lat, Lon: lon}, params)
+		if err != nil {
+			return err
+		}
+		routes, err := cmd.Flags().GetBool("routes")
+		if err != nil {
+			return err
+		}
+		if routes {
+			rs, err := client.RoutesForLocation(params)
+			if err != nil {
+				return err
+			}
+			return output(cmd, NearbyRoutes(rs, stops))
+		}
+
+		n, err := cmd.Flags().GetInt("arrivals")
+		if err != nil {
+			return err
+		}
+		for i := 0; i < n && i < len(stops); i++ {
+			sad, err := client.ArrivalsAndDeparturesForStop(stops[i].Stop.ID, map[string]string{"minutesBefore": "0"})
+			if err != nil {
+				logger.Log(oba.LevelWarn, "arrivals failed", oba.F("stop", stops[i].Stop.ID), oba.F("error", err))
+				continue
+			}
+			if sad == nil {
+				logger.Log(oba.LevelWarn, "no arrivals", oba.F("stop", stops[i].Stop.ID))
+				continue
+			}
+			stops[i].Arrivals = sad.ArrivalsAndDepartures
+		}
+		return output(cmd, stops)
+	},
+}
+
+// NearbyStops - the stops of StopsForLocation with their distance from loc,
+// nearest first
+func NearbyStops(client oba.Client, loc oba.Location, params map[string]string) ([]NearbyStop, error) {
+	ss, err := client.StopsForLocation(params)
+	if err != nil {
+		return nil, err
+	}
+	stops := make([]NearbyStop, 0, len(ss))
+	for _, s := range ss {
+		stops = append(stops, NearbyStop{Stop: s, Distance: loc.DistanceTo(s.Location())})
+	}
+	sort.SliceStable(stops, func(i, j int) bool {
+		return stops[i].Distance < stops[j].Distance
+	})
+	return stops, nil
+}
+
+// NearbyRoutes - rs ordered by the distance of the nearest of stops serving
+// each, routes without a serving stop last
+func NearbyRoutes(rs []oba.Route, stops []NearbyStop) []NearbyRoute {
+	nearest := make(map[string]NearbyStop)
+	for _, s := range stops {
+		for _, r := range s.Stop.Routes {
+			if _, ok := nearest[r.ID]; !ok {
+				nearest[r.ID] = s
+			}
+		}
+	}
+	routes := make([]NearbyRoute, 0, len(rs))
+	for _, r := range rs {
+		nr := NearbyRoute{Route: r}
+		if s, ok := nearest[r.ID]; ok {
+			nr.Distance, nr.NearestStop = s.Distance, s.Stop.ID
+		}
+		routes = append(routes, nr)
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		a, b := routes[i], routes[j]
+		if (a.NearestStop == "") != (b.NearestStop == "") {
+			return b.NearestStop == ""
+		}
+		return a.Distance < b.Distance
+	})
+	return routes
+}
+
+func meters(m float64) string {
+	return strconv.Itoa(int(m+0.5)) + "m"
+}
+
+// nextDepartures - up to three departures as "<route> <minutes>m"
+func nextDepartures(ads []oba.ArrivalAndDeparture) string {
+	var next []string
+	for _, a := range ads {
+		if len(next) == 3 {
+			break
+		}
+		m := minutesAway(arrivalTime(a))
+		if m != "now" {
+			m += "m"
+		}
+		next = append(next, arrivalRoute(a)+" "+m)
+	}
+	return strings.Join(next, ", ")
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNearbyStops(t *testing.T) {
+	// about 111m for each 0.001 degrees of latitude
+	client := &fakeClient{stops: []oba.Stop{
+		{ID: "far", Lat: 47.003, Lon: -122},
+		{ID: "near", Lat: 47.001, Lon: -122},
+		{ID: "here", Lat: 47, Lon: -122},
+		{ID: "south", Lat: 46.998, Lon: -122},
+	}}
+	stops, err := NearbyStops(client, oba.Location{Lat: 47, Lon: -122}, nil)
+	assert.NoError(t, err)
+
+	var ids []string
+	for _, s := range stops {
+		ids = append(ids, s.Stop.ID)
+	}
+	assert.Equal(t, []string{"here", "near", "south", "far"}, ids, "nearest first")
+	assert.Zero(t, stops[0].Distance)
+	assert.InDelta(t, 111, stops[1].Distance, 1)
+	assert.InDelta(t, 334, stops[3].Distance, 1)
+}
+
+func TestNearbyRoutes(t *testing.T) {
+	stops := []NearbyStop{
+		{Stop: oba.Stop{ID: "s1", Routes: []oba.Route{{ID: "r44"}}}, Distance: 50},
+		{Stop: oba.Stop{ID: "s2", Routes: []oba.Route{{ID: "r48"}, {ID: "r44"}}}, Distance: 120},
+		{Stop: oba.Stop{ID: "s3", Routes: []oba.Route{{ID: "r8"}}}, Distance: 300},
+	}
+	tests := []struct {
+		name   string
+		routes []string
+		want   []NearbyRoute
+	}{
+		{"by the nearest serving stop", []string{"r8", "r48", "r44"}, []NearbyRoute{
+			{Route: oba.Route{ID: "r44"}, Distance: 50, NearestStop: "s1"},
+			{Route: oba.Route{ID: "r48"}, Distance: 120, NearestStop: "s2"},
+			{Route: oba.Route{ID: "r8"}, Distance: 300, NearestStop: "s3"},
+		}},
+		{"routes without a serving stop last", []string{"r99", "r8", "r98", "r44"}, []NearbyRoute{
+			{Route: oba.Route{ID: "r44"}, Distance: 50, NearestStop: "s1"},
+			{Route: oba.Route{ID: "r8"}, Distance: 300, NearestStop: "s3"},
+			{Route: oba.Route{ID: "r99"}},
+			{Route: oba.Route{ID: "r98"}},
+		}},
+		{"no stops", nil, []NearbyRoute{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var rs []oba.Route
+			for _, id := range test.routes {
+				rs = append(rs, oba.Route{ID: id})
+			}
+			assert.Equal(t, test.want, NearbyRoutes(rs, stops))
+		})
+	}
+}
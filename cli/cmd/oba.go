@@ -2,39 +2,57 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/spf13/viper"
 	"os"
 
+	"github.com/Setheck/oba"
 	"github.com/spf13/cobra"
 )
 
-var baseUrl string
-var apiKey string
+var logLevel string
+var logger = oba.NopLogger
 
 func init() {
+	cobra.OnInitialize(initLogger, initConfig)
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "warn", "log level: debug, info, warn, error or off")
 	rootCmd.AddCommand(
 		agencyCmd, blockCmd, reportCmd, routeCmd, stopCmd, tripCmd)
 }
 
+// initLogger - log to stderr at the --log-level, keeping stdout for results
+func initLogger() {
+	if logLevel == "off" {
+		logger = oba.NopLogger
+		return
+	}
+	level, err := oba.ParseLevel(logLevel)
+	if err != nil {
+		level = oba.LevelWarn
+	}
+	logger = oba.NewTextLogger(os.Stderr, level)
+	if err != nil {
+		logger.Log(oba.LevelWarn, "invalid --log-level, using warn", oba.F("error", err))
+	}
+}
+
+// newClient - a client for the configured server logging to the cli logger
+func newClient() *oba.DefaultClient {
+	client := oba.NewDefaultClientS(baseUrl, apiKey)
+	client.SetLogger(logger)
+	return client
+}
+
 var rootCmd = &cobra.Command{
 	Use:   "oba",
 	Short: "OneBusAway Cli tool",
 	Long:  "OBA Cli tool!",
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("what do you want? How about try -h?")
+		fmt.Fprintln(os.Stderr, "what do you want? How about try -h?")
 	},
 }
 
 func Execute() {
-	if v := viper.Get("baseUrl"); v != nil {
-		baseUrl = v.(string)
-	}
-	if v := viper.Get("apiKey"); v != nil {
-		apiKey = v.(string)
-	}
-
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
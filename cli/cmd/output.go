@@ -0,0 +1,307 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// output formats
+const (
+	formatJSON   = "json"
+	formatNDJSON = "ndjson"
+	formatYAML   = "yaml"
+	formatCSV    = "csv"
+	formatTable  = "table"
+)
+
+var formats = []string{formatJSON, formatNDJSON, formatYAML, formatCSV, formatTable}
+
+var outputFormat string
+var outputFields []string
+var outputPretty bool
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&outputFormat, "output", "o", formatJSON,
+		"output format: "+strings.Join(formats, ", "))
+	rootCmd.PersistentFlags().StringSliceVar(&outputFields, "fields", nil,
+		"comma separated columns to output, list them with 'oba fields'")
+	rootCmd.PersistentFlags().BoolVar(&outputPretty, "pretty", false,
+		"indent json output, by default each result is a single line")
+	rootCmd.AddCommand(fieldsCmd)
+}
+
+var fieldsCmd = &cobra.Command{
+	Use:   "fields",
+	Short: "list the columns available to --fields",
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Fprintln(cmd.OutOrStdout(), fieldsHelp())
+	},
+}
+
+// Column - a named value of a result shown by the table and csv formats
+type Column struct {
+	Name  string
+	Value func(v interface{}) string
+}
+
+// columnSets - the default columns of each result type
+var columnSets = map[reflect.Type][]Column{}
+
+// registerColumns - set the columns of the type of sample
+func registerColumns(sample interface{}, cols ...Column) {
+	columnSets[reflect.TypeOf(sample)] = cols
+}
+
+// printer - writes results in the selected format
+type printer struct {
+	w      io.Writer
+	format string
+	fields []string
+	pretty bool
+}
+
+func newPrinter(w io.Writer) (*printer, error) {
+	for _, f := range formats {
+		if f == outputFormat {
+			return &printer{w: w, format: outputFormat, fields: outputFields, pretty: outputPretty}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown output format %q, use one of %s", outputFormat, strings.Join(formats, ", "))
+}
+
+// output - write v, a result or slice of results, to stdout in the selected
+// format
+func output(cmd *cobra.Command, v interface{}) error {
+	p, err := newPrinter(cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+	return p.print(v)
+}
+
+func (p *printer) print(v interface{}) error {
+	items := elements(v)
+	if len(p.fields) > 0 || p.format == formatTable || p.format == formatCSV {
+		cols, err := p.columns(v)
+		if err != nil {
+			return err
+		}
+		if p.format == formatTable || p.format == formatCSV {
+			return p.tabular(cols, items)
+		}
+		rows := make([]interface{}, 0, len(items))
+		for _, it := range items {
+			row := make(map[string]string, len(cols))
+			for _, c := range cols {
+				row[c.Name] = c.Value(it)
+			}
+			rows = append(rows, row)
+		}
+		items = rows
+		if isSlice(v) {
+			v = rows
+		} else if len(rows) == 1 {
+			v = rows[0]
+		}
+	}
+
+	switch p.format {
+	case formatNDJSON:
+		enc := json.NewEncoder(p.w)
+		for _, it := range items {
+			if err := enc.Encode(it); err != nil {
+				return err
+			}
+		}
+		return nil
+	case formatYAML:
+		// round trip through json so keys match the json output
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		var generic interface{}
+		if err := json.Unmarshal(b, &generic); err != nil {
+			return err
+		}
+		out, err := yaml.Marshal(generic)
+		if err != nil {
+			return err
+		}
+		_, err = p.w.Write(out)
+		return err
+	default:
+		enc := json.NewEncoder(p.w)
+		if p.pretty {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(v)
+	}
+}
+
+func (p *printer) tabular(cols []Column, items []interface{}) error {
+	names := make([]string, 0, len(cols))
+	for _, c := range cols {
+		names = append(names, c.Name)
+	}
+	if p.format == formatCSV {
+		w := csv.NewWriter(p.w)
+		if err := w.Write(names); err != nil {
+			return err
+		}
+		for _, it := range items {
+			if err := w.Write(row(cols, it)); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		return w.Error()
+	}
+
+	w := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.ToUpper(strings.Join(names, "\t")))
+	for _, it := range items {
+		fmt.Fprintln(w, strings.Join(row(cols, it), "\t"))
+	}
+	return w.Flush()
+}
+
+func row(cols []Column, v interface{}) []string {
+	fields := make([]string, 0, len(cols))
+	for _, c := range cols {
+		fields = append(fields, c.Value(v))
+	}
+	return fields
+}
+
+// columns - the columns for the type of v, narrowed to --fields
+func (p *printer) columns(v interface{}) ([]Column, error) {
+	cols := columnsFor(elementType(v))
+	if len(p.fields) == 0 {
+		return cols, nil
+	}
+	byName := make(map[string]Column, len(cols))
+	names := make([]string, 0, len(cols))
+	for _, c := range cols {
+		byName[strings.ToLower(c.Name)] = c
+		names = append(names, c.Name)
+	}
+	var selected []Column
+	for _, f := range p.fields {
+		c, ok := byName[strings.ToLower(strings.TrimSpace(f))]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q, available fields: %s", f, strings.Join(names, ", "))
+		}
+		selected = append(selected, c)
+	}
+	return selected, nil
+}
+
+// columnsFor - the registered columns of t, or a column per exported field
+// holding a plain value
+func columnsFor(t reflect.Type) []Column {
+	if t == nil {
+		return nil
+	}
+	if cols, ok := columnSets[t]; ok {
+		return cols
+	}
+	if t.Kind() != reflect.Struct {
+		return []Column{{Name: "value", Value: func(v interface{}) string { return fmt.Sprint(v) }}}
+	}
+	var cols []Column
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" || !plain(f.Type) {
+			continue
+		}
+		idx := i
+		cols = append(cols, Column{
+			Name: strings.ToLower(f.Name),
+			Value: func(v interface{}) string {
+				return fmt.Sprint(reflect.Indirect(reflect.ValueOf(v)).Field(idx).Interface())
+			},
+		})
+	}
+	return cols
+}
+
+func plain(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64, reflect.String:
+		return true
+	}
+	return false
+}
+
+func isSlice(v interface{}) bool {
+	return v != nil && reflect.TypeOf(v).Kind() == reflect.Slice
+}
+
+// elements - the dereferenced results held by v
+func elements(v interface{}) []interface{} {
+	if v == nil {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice {
+		if e := reflect.Indirect(rv); e.IsValid() {
+			return []interface{}{e.Interface()}
+		}
+		return nil
+	}
+	items := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		if e := reflect.Indirect(rv.Index(i)); e.IsValid() {
+			items = append(items, e.Interface())
+		}
+	}
+	return items
+}
+
+// elementType - the dereferenced type of the results held by v
+func elementType(v interface{}) reflect.Type {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// fieldsHelp - the columns of every registered type, for --fields
+func fieldsHelp() string {
+	var lines []string
+	for t, cols := range columnSets {
+		names := make([]string, 0, len(cols))
+		for _, c := range cols {
+			names = append(names, c.Name)
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s", t.Name(), strings.Join(names, ", ")))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n")
+}
+
+// clock - the local time of a time in milliseconds, empty for zero
+func clock(ms int) string {
+	if ms == 0 {
+		return ""
+	}
+	return oba.MillisToTime(ms).Local().Format("15:04")
+}
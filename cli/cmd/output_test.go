@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testResult - a result without registered columns, its plain fields are the
+// columns
+type testResult struct {
+	ID    string
+	Count int
+	Tags  []string
+}
+
+func TestPrinter_Print(t *testing.T) {
+	results := []testResult{{ID: "a", Count: 1, Tags: []string{"x"}}, {ID: "b", Count: 2}}
+	tests := []struct {
+		name   string
+		p      printer
+		v      interface{}
+		output string
+		err    string
+	}{
+		{"json", printer{format: formatJSON}, results[0],
+			`{"ID":"a","Count":1,"Tags":["x"]}` + "\n", ""},
+		{"json slice", printer{format: formatJSON}, results,
+			`[{"ID":"a","Count":1,"Tags":["x"]},{"ID":"b","Count":2,"Tags":null}]` + "\n", ""},
+		{"json pretty", printer{format: formatJSON, pretty: true}, results[1],
+			"{\n  \"ID\": \"b\",\n  \"Count\": 2,\n  \"Tags\": null\n}\n", ""},
+		{"ndjson", printer{format: formatNDJSON}, results,
+			`{"ID":"a","Count":1,"Tags":["x"]}` + "\n" + `{"ID":"b","Count":2,"Tags":null}` + "\n", ""},
+		{"yaml", printer{format: formatYAML}, results[0],
+			"Count: 1\nID: a\nTags:\n- x\n", ""},
+		{"csv", printer{format: formatCSV}, results,
+			"id,count\na,1\nb,2\n", ""},
+		{"table", printer{format: formatTable}, results,
+			"ID  COUNT\na   1\nb   2\n", ""},
+		{"table pointer", printer{format: formatTable}, &results[1],
+			"ID  COUNT\nb   2\n", ""},
+		{"fields", printer{format: formatJSON, fields: []string{"Count"}}, results,
+			`[{"count":"1"},{"count":"2"}]` + "\n", ""},
+		{"fields csv", printer{format: formatCSV, fields: []string{"count", "id"}}, results,
+			"count,id\n1,a\n2,b\n", ""},
+		{"unknown field", printer{format: formatTable, fields: []string{"name"}}, results,
+			"", `unknown field "name", available fields: id, count`},
+		{"strings", printer{format: formatTable}, []string{"1_1", "1_2"},
+			"ID\n1_1\n1_2\n", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			p := test.p
+			p.w = &buf
+			err := p.print(test.v)
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Equal(t, test.err, err.Error())
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.output, buf.String())
+		})
+	}
+}
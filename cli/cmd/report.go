@@ -1,20 +1,172 @@
 package cmd
 
 import (
-	"github.com/Setheck/oba"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/spf13/cobra"
 )
 
+// stopProblemCodes - the problem codes accepted by report-problem-with-stop
+var stopProblemCodes = []string{
+	"stop_name_wrong",
+	"stop_number_wrong",
+	"stop_location_wrong",
+	"route_or_trip_missing",
+	"other",
+}
+
+// tripProblemCodes - the problem codes accepted by report-problem-with-trip
+var tripProblemCodes = []string{
+	"vehicle_never_came",
+	"vehicle_came_early",
+	"vehicle_came_late",
+	"wrong_headsign",
+	"vehicle_does_not_stop_here",
+	"other",
+}
+
+func init() {
+	reportFlags(reportStopCmd, stopProblemCodes)
+	reportFlags(reportTripCmd, tripProblemCodes)
+	reportTripCmd.Flags().String("service-date", "", "service date of the trip as YYYY-MM-DD or milliseconds since the epoch")
+	reportTripCmd.Flags().String("vehicle", "", "id of the vehicle serving the trip")
+	reportTripCmd.Flags().String("vehicle-number", "", "vehicle number as seen by the user")
+	reportTripCmd.Flags().String("stop", "", "id of the stop where the problem happened")
+	reportTripCmd.Flags().Bool("on-vehicle", false, "the user is on the vehicle")
+	reportCmd.AddCommand(reportStopCmd, reportTripCmd)
+}
+
 var reportCmd = &cobra.Command{
 	Use:   "report",
 	Short: "report things",
-	Long:  "send a report",
+	Long:  "send a problem report for a stop or a trip",
+}
+
+var reportStopCmd = &cobra.Command{
+	Use:   "stop <id>",
+	Short: "report a problem with a stop",
+	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := oba.NewDefaultClientS(baseUrl, apiKey)
-		id, err := cmd.Flags().GetString("id")
+		client := newClient()
+		params, err := reportParams(cmd, stopProblemCodes)
+		if err != nil {
+			return err
+		}
+		if err := client.ReportProblemWithStop(args[0], params); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "reported %s for stop %s\n", params["code"], args[0])
+		return nil
+	},
+}
+
+var reportTripCmd = &cobra.Command{
+	Use:   "trip <id>",
+	Short: "report a problem with a trip",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		params, err := reportParams(cmd, tripProblemCodes)
+		if err != nil {
+			return err
+		}
+		date, err := cmd.Flags().GetString("service-date")
 		if err != nil {
 			return err
 		}
-		return client.ReportProblemWithStop(id, nil)
+		if date != "" {
+			if params["serviceDate"], err = serviceDateMillis(date); err != nil {
+				return err
+			}
+		}
+		for flag, param := range map[string]string{
+			"vehicle":        "vehicleId",
+			"vehicle-number": "userVehicleNumber",
+			"stop":           "stopId",
+		} {
+			v, err := cmd.Flags().GetString(flag)
+			if err != nil {
+				return err
+			}
+			if v != "" {
+				params[param] = v
+			}
+		}
+		if cmd.Flags().Changed("on-vehicle") {
+			onVehicle, err := cmd.Flags().GetBool("on-vehicle")
+			if err != nil {
+				return err
+			}
+			params["userOnVehicle"] = strconv.FormatBool(onVehicle)
+		}
+		if err := client.ReportProblemWithTrip(args[0], params); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "reported %s for trip %s\n", params["code"], args[0])
+		return nil
 	},
 }
+
+// reportFlags - define the flags read by reportParams on c
+func reportFlags(c *cobra.Command, codes []string) {
+	c.Flags().String("code", "", "problem code: "+strings.Join(codes, ", "))
+	c.Flags().String("comment", "", "comment describing the problem")
+	c.Flags().Float64("lat", 0, "latitude of the reporting user")
+	c.Flags().Float64("lon", 0, "longitude of the reporting user")
+	c.Flags().Int("accuracy", 0, "accuracy of the user location in meters")
+}
+
+// reportParams - the parameters shared by stop and trip reports, the code must
+// be one of codes
+func reportParams(cmd *cobra.Command, codes []string) (map[string]string, error) {
+	code, err := cmd.Flags().GetString("code")
+	if err != nil {
+		return nil, err
+	}
+	if !validCode(code, codes) {
+		return nil, fmt.Errorf("invalid --code %q, use one of %s", code, strings.Join(codes, ", "))
+	}
+	params := map[string]string{"code": code}
+
+	comment, err := cmd.Flags().GetString("comment")
+	if err != nil {
+		return nil, err
+	}
+	if comment != "" {
+		params["userComment"] = comment
+	}
+	if cmd.Flags().Changed("lat") != cmd.Flags().Changed("lon") {
+		return nil, fmt.Errorf("--lat and --lon must be given together")
+	}
+	if cmd.Flags().Changed("lat") {
+		lat, err := cmd.Flags().GetFloat64("lat")
+		if err != nil {
+			return nil, err
+		}
+		lon, err := cmd.Flags().GetFloat64("lon")
+		if err != nil {
+			return nil, err
+		}
+		params["userLat"] = strconv.FormatFloat(lat, 'f', -1, 64)
+		params["userLon"] = strconv.FormatFloat(lon, 'f', -1, 64)
+	}
+	accuracy, err := cmd.Flags().GetInt("accuracy")
+	if err != nil {
+		return nil, err
+	}
+	if accuracy > 0 {
+		params["userLocationAccuracy"] = strconv.Itoa(accuracy)
+	}
+	return params, nil
+}
+
+func validCode(code string, codes []string) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		params map[string]string
+		err    string
+	}{
+		{"code only", []string{"--code", "stop_name_wrong"},
+			map[string]string{"code": "stop_name_wrong"}, ""},
+		{"all", []string{"--code", "other", "--comment", "moved", "--lat", "47.6", "--lon", "-122.3", "--accuracy", "20"},
+			map[string]string{"code": "other", "userComment": "moved", "userLat": "47.6", "userLon": "-122.3",
+				"userLocationAccuracy": "20"}, ""},
+		{"zero location", []string{"--code", "other", "--lat", "0", "--lon", "0"},
+			map[string]string{"code": "other", "userLat": "0", "userLon": "0"}, ""},
+		{"no code", nil, nil, `invalid --code ""`},
+		{"trip code", []string{"--code", "vehicle_never_came"}, nil, `invalid --code "vehicle_never_came"`},
+		{"lat without lon", []string{"--code", "other", "--lat", "47.6"}, nil, "--lat and --lon must be given together"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			reportFlags(cmd, stopProblemCodes)
+			if !assert.NoError(t, cmd.ParseFlags(test.args)) {
+				return
+			}
+			params, err := reportParams(cmd, stopProblemCodes)
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Contains(t, err.Error(), test.err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.params, params)
+		})
+	}
+}
@@ -2,50 +2,181 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/Setheck/oba"
 	"github.com/spf13/cobra"
 )
 
+// RouteStop - a stop of a route in the order served in one direction
+type RouteStop struct {
+	Direction string
+	Sequence  int
+	Stop      oba.Stop
+}
+
 func init() {
 	routeCmd.Flags().String("id", "", "route id for lookup")
-	routeCmd.Flags().String("aid", "", "route ids for agency id [aid] lookup")
+	routeCmd.Flags().String("aid", "", "list the routes of agency id [aid]")
+	routeCmd.Flags().Bool("ids", false, "list only the route ids of --aid")
+	routeCmd.Flags().String("near", "", "list the routes near lat,lon")
+	routeCmd.Flags().Int("radius", 0, "search radius of --near in meters, the server default when 0")
+	routeCmd.AddCommand(routeStopsCmd)
+
+	registerColumns(RouteStop{},
+		Column{"direction", func(v interface{}) string { return v.(RouteStop).Direction }},
+		Column{"seq", func(v interface{}) string { return strconv.Itoa(v.(RouteStop).Sequence) }},
+		Column{"id", func(v interface{}) string { return v.(RouteStop).Stop.ID }},
+		Column{"code", func(v interface{}) string { return v.(RouteStop).Stop.Code }},
+		Column{"name", func(v interface{}) string { return v.(RouteStop).Stop.Name }},
+		Column{"lat", func(v interface{}) string { return coord(v.(RouteStop).Stop.Lat) }},
+		Column{"lon", func(v interface{}) string { return coord(v.(RouteStop).Stop.Lon) }},
+	)
 }
 
 var routeCmd = &cobra.Command{
 	Use:   "route",
 	Short: "retrieve routes",
-	Long:  "get some routes",
+	Long:  "get a route by --id, list the routes of an agency with --aid or near a location with --near",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := oba.NewDefaultClientS(baseUrl, apiKey)
+		client := newClient()
+		id, err := cmd.Flags().GetString("id")
+		if err != nil {
+			return err
+		}
 		aid, err := cmd.Flags().GetString("aid")
 		if err != nil {
 			return err
 		}
-		return RouteIdsForAgency(client, aid)
-
-		id, err := cmd.Flags().GetString("id")
+		near, err := cmd.Flags().GetString("near")
 		if err != nil {
 			return err
 		}
-		return Route(client, id)
+
+		switch {
+		case id != "":
+			return Route(cmd, client, id)
+		case aid != "":
+			idsOnly, err := cmd.Flags().GetBool("ids")
+			if err != nil {
+				return err
+			}
+			if idsOnly {
+				return RouteIdsForAgency(cmd, client, aid)
+			}
+			return RoutesForAgency(cmd, client, aid)
+		case near != "":
+			loc, err := parseLatLon(near)
+			if err != nil {
+				return err
+			}
+			radius, err := cmd.Flags().GetInt("radius")
+			if err != nil {
+				return err
+			}
+			return RoutesNear(cmd, client, loc, radius)
+		}
+		return fmt.Errorf("--id, --aid or --near is required")
 	},
 }
 
-func Route(client oba.Client, id string) error {
+var routeStopsCmd = &cobra.Command{
+	Use:   "stops <id>",
+	Short: "stops of a route",
+	Long:  "list the stops of a route in order for each direction",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		return RouteStops(cmd, client, args[0])
+	},
+}
+
+func Route(cmd *cobra.Command, client oba.Client, id string) error {
 	route, err := client.Route(id)
 	if err != nil {
 		return err
 	}
-	fmt.Println(route.String())
-	return nil
+	return output(cmd, route)
 }
 
-func RouteIdsForAgency(client oba.Client, agency string) error {
+func RouteIdsForAgency(cmd *cobra.Command, client oba.Client, agency string) error {
 	ids, err := client.RouteIdsForAgency(agency)
 	if err != nil {
 		return err
 	}
-	fmt.Printf("%s\n", ids)
-	return nil
+	return output(cmd, ids)
+}
+
+func RoutesForAgency(cmd *cobra.Command, client oba.Client, agency string) error {
+	routes, err := client.RoutesForAgency(agency)
+	if err != nil {
+		return err
+	}
+	return output(cmd, routes)
+}
+
+// RoutesNear - print the routes around loc, within radius meters when
+// positive
+func RoutesNear(cmd *cobra.Command, client oba.Client, loc oba.Location, radius int) error {
+	params := oba.LocationParams(loc.Lat, loc.Lon, "")
+	if radius > 0 {
+		params["radius"] = strconv.Itoa(radius)
+	}
+	routes, err := client.RoutesForLocation(params)
+	if err != nil {
+		return err
+	}
+	return output(cmd, routes)
+}
+
+// RouteStops - print the stops of route id in order for each direction of its
+// stop groupings
+func RouteStops(cmd *cobra.Command, client oba.Client, id string) error {
+	sfr, err := client.StopsForRoute(id)
+	if err != nil {
+		return err
+	}
+	if sfr == nil {
+		return fmt.Errorf("no stops for route %s", id)
+	}
+	var stops []RouteStop
+	for _, g := range sfr.StopGroupings {
+		if g.Type != "direction" {
+			continue
+		}
+		for _, sg := range g.StopGroups {
+			direction := sg.Name.Name
+			if direction == "" {
+				direction = sg.ID
+			}
+			for i, s := range sg.Stops {
+				stops = append(stops, RouteStop{Direction: direction, Sequence: i + 1, Stop: s})
+			}
+		}
+	}
+	// without direction groupings the stops have no known order
+	if len(stops) == 0 {
+		for i, s := range sfr.Stops {
+			stops = append(stops, RouteStop{Sequence: i + 1, Stop: s})
+		}
+	}
+	return output(cmd, stops)
+}
+
+// parseLatLon - a location written as "lat,lon"
+func parseLatLon(s string) (oba.Location, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return oba.Location{}, fmt.Errorf("invalid location %q, use lat,lon", s)
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return oba.Location{}, fmt.Errorf("invalid latitude %q", parts[0])
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return oba.Location{}, fmt.Errorf("invalid longitude %q", parts[1])
+	}
+	return oba.Location{Lat: lat, Lon: lon}, nil
 }
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteStops(t *testing.T) {
+	a, b, c := oba.Stop{ID: "a"}, oba.Stop{ID: "b"}, oba.Stop{ID: "c"}
+	client := &fakeClient{sfrs: map[string]*oba.StopsForRoute{
+		"grouped": {
+			Stops: []oba.Stop{c, a, b},
+			StopGroupings: []oba.StopGrouping{
+				{Type: "other", StopGroups: []oba.StopGroup{{ID: "x", Stops: []oba.Stop{c}}}},
+				{Type: "direction", StopGroups: []oba.StopGroup{
+					{ID: "0", Name: oba.Name{Name: "Ballard"}, Stops: []oba.Stop{a, b, c}},
+					{ID: "1", Stops: []oba.Stop{c, b}},
+				}},
+			},
+		},
+		"ungrouped": {Stops: []oba.Stop{b, a}},
+		"nil":       nil,
+	}}
+	tests := []struct {
+		name  string
+		route string
+		want  []string
+		err   string
+	}{
+		{"by direction in order", "grouped", []string{"Ballard 1 a", "Ballard 2 b", "Ballard 3 c", "1 1 c", "1 2 b"}, ""},
+		{"without groupings", "ungrouped", []string{" 1 b", " 2 a"}, ""},
+		{"no stops entry", "nil", nil, "no stops for route nil"},
+		{"request failed", "missing", nil, "not found"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stops []RouteStop
+			err := runOutput(t, &stops, func(cmd *cobra.Command) error {
+				return RouteStops(cmd, client, test.route)
+			})
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Equal(t, test.err, err.Error())
+				}
+				return
+			}
+			assert.NoError(t, err)
+			var got []string
+			for _, s := range stops {
+				got = append(got, s.Direction+" "+strconv.Itoa(s.Sequence)+" "+s.Stop.ID)
+			}
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
+func TestParseLatLon(t *testing.T) {
+	tests := []struct {
+		in   string
+		want oba.Location
+		err  string
+	}{
+		{"47.6097,-122.3331", oba.Location{Lat: 47.6097, Lon: -122.3331}, ""},
+		{" 47.6097 , -122.3331 ", oba.Location{Lat: 47.6097, Lon: -122.3331}, ""},
+		{"47.6097", oba.Location{}, `invalid location "47.6097", use lat,lon`},
+		{"47.6,-122.3,10", oba.Location{}, `invalid location "47.6,-122.3,10", use lat,lon`},
+		{"north,-122.3", oba.Location{}, `invalid latitude "north"`},
+		{"47.6,", oba.Location{}, `invalid longitude ""`},
+	}
+	for _, test := range tests {
+		t.Run(test.in, func(t *testing.T) {
+			loc, err := parseLatLon(test.in)
+			if test.err != "" {
+				if assert.Error(t, err) {
+					assert.Equal(t, test.err, err.Error())
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, loc)
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+)
+
+// ScheduleEntry - a departure, or a frequency based window of departures, in
+// a stop timetable
+type ScheduleEntry struct {
+	RouteID  string
+	Route    string
+	Headsign string
+	Departs  time.Time
+	// Until - the end of a frequency based window, nil for a departure
+	Until *time.Time `json:",omitempty"`
+	// Every - the headway in seconds of a frequency based window
+	Every  int    `json:",omitempty"`
+	TripID string `json:",omitempty"`
+	// Next - the next departure of the route and direction
+	Next bool
+}
+
+func init() {
+	scheduleCmd.Flags().String("stop", "", "stop id")
+	scheduleCmd.Flags().String("date", "", "service date as YYYY-MM-DD, today when empty")
+	scheduleCmd.Flags().StringSlice("route", nil, "only show these route ids or short names")
+	rootCmd.AddCommand(scheduleCmd)
+
+	registerColumns(ScheduleEntry{},
+		Column{"route", func(v interface{}) string { return v.(ScheduleEntry).Route }},
+		Column{"headsign", func(v interface{}) string { return v.(ScheduleEntry).Headsign }},
+		Column{"departs", func(v interface{}) string { return departs(v.(ScheduleEntry)) }},
+		Column{"every", func(v interface{}) string { return every(v.(ScheduleEntry).Every) }},
+		Column{"trip", func(v interface{}) string { return v.(ScheduleEntry).TripID }},
+		Column{"next", func(v interface{}) string { return nextMark(v.(ScheduleEntry).Next) }},
+	)
+}
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "timetable of a stop",
+	Long:  "show the departures at a stop for a day grouped by route and direction in agency time",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		id, err := cmd.Flags().GetString("stop")
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			return fmt.Errorf("--stop is required")
+		}
+		d, err := cmd.Flags().GetString("date")
+		if err != nil {
+			return err
+		}
+		var date time.Time
+		if d != "" {
+			if date, err = time.Parse("2006-01-02", d); err != nil {
+				return fmt.Errorf("invalid --date %q, use YYYY-MM-DD", d)
+			}
+		}
+		routes, err := cmd.Flags().GetStringSlice("route")
+		if err != nil {
+			return err
+		}
+
+		ss, err := client.ScheduleForStop(id, date)
+		if err != nil {
+			return err
+		}
+		if ss == nil {
+			return fmt.Errorf("no schedule for stop %s", id)
+		}
+		groups := Timetable(ss, routes, now())
+		if outputFormat != formatTable {
+			var entries []ScheduleEntry
+			for _, g := range groups {
+				entries = append(entries, g...)
+			}
+			return output(cmd, entries)
+		}
+		return printTimetable(cmd, ss, groups)
+	},
+}
+
+// Timetable - the departures of ss served by any of routes, all when empty,
+// one group per route and direction ordered by time. When at is on the service
+// date of ss the first departure after at is marked next, a zero at marks none.
+func Timetable(ss *oba.StopSchedule, routes []string, at time.Time) [][]ScheduleEntry {
+	loc := scheduleLocation(ss)
+	mark := !at.IsZero() && (ss.Date == 0 || sameDay(oba.MillisToTime(ss.Date).In(loc), at.In(loc)))
+	want := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		want[r] = true
+	}
+
+	var groups [][]ScheduleEntry
+	for _, rs := range ss.StopRouteSchedules {
+		if len(want) > 0 && !want[rs.Route.ID] && !want[rs.Route.ShortName] {
+			continue
+		}
+		name := rs.Route.ShortName
+		if name == "" {
+			name = rs.Route.ID
+		}
+		for _, ds := range rs.StopRouteDirectionSchedules {
+			var g []ScheduleEntry
+			for _, st := range ds.ScheduleStopTimes {
+				t := st.DepartureTime
+				if t == 0 {
+					t = st.ArrivalTime
+				}
+				g = append(g, ScheduleEntry{
+					RouteID:  rs.Route.ID,
+					Route:    name,
+					Headsign: ds.TripHeadsign,
+					Departs:  oba.MillisToTime(t).In(loc),
+					TripID:   st.TripID,
+				})
+			}
+			for _, f := range ds.ScheduleFrequencies {
+				until := oba.MillisToTime(f.EndTime).In(loc)
+				g = append(g, ScheduleEntry{
+					RouteID:  rs.Route.ID,
+					Route:    name,
+					Headsign: ds.TripHeadsign,
+					Departs:  oba.MillisToTime(f.StartTime).In(loc),
+					Until:    &until,
+					Every:    f.Headway,
+					TripID:   f.TripID,
+				})
+			}
+			if len(g) == 0 {
+				continue
+			}
+			sort.SliceStable(g, func(i, j int) bool {
+				return g[i].Departs.Before(g[j].Departs)
+			})
+			if mark {
+				markNext(g, at)
+			}
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// markNext - mark the first entry of g departing at or after at, or a window
+// running at at
+func markNext(g []ScheduleEntry, at time.Time) {
+	for i, e := range g {
+		if !e.Departs.Before(at) || (e.Until != nil && e.Until.After(at)) {
+			g[i].Next = true
+			return
+		}
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// printTimetable - each group as its own table under a route and direction
+// heading
+func printTimetable(cmd *cobra.Command, ss *oba.StopSchedule, groups [][]ScheduleEntry) error {
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "%s %s, %s\n", ss.Stop.ID, ss.Stop.Name, day(ss.Date))
+	for _, g := range groups {
+		fmt.Fprintf(w, "\n%s to %s\n", g[0].Route, g[0].Headsign)
+		if err := output(cmd, g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scheduleLocation - the time zone of the schedule, or the agency of the stop,
+// local time when neither is known
+func scheduleLocation(ss *oba.StopSchedule) *time.Location {
+	tz := ss.TimeZone
+	if tz == "" {
+		for _, r := range ss.Stop.Routes {
+			if r.Agency.TimeZone != "" {
+				tz = r.Agency.TimeZone
+				break
+			}
+		}
+	}
+	if loc, err := time.LoadLocation(tz); err == nil && tz != "" {
+		return loc
+	}
+	return time.Local
+}
+
+// departs - the departure time, or the span of a frequency based window
+func departs(e ScheduleEntry) string {
+	if e.Until == nil {
+		return e.Departs.Format("15:04")
+	}
+	return e.Departs.Format("15:04") + "-" + e.Until.Format("15:04")
+}
+
+// every - a headway in seconds as "every N min"
+func every(secs int) string {
+	if secs == 0 {
+		return ""
+	}
+	return fmt.Sprintf("every %d min", (secs+30)/60)
+}
+
+func nextMark(next bool) string {
+	if next {
+		return "<- next"
+	}
+	return ""
+}
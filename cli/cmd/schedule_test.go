@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+func millis(t time.Time) int {
+	return int(t.UnixNano() / int64(time.Millisecond))
+}
+
+// testStopSchedule - route 44 departs at 08:00, 08:30 and 09:00 and every ten
+// minutes from 10:00 to 12:00, route 48 at 08:10, on the 20th of September
+func testStopSchedule(t *testing.T) (*oba.StopSchedule, *time.Location) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skip("no time zone data:", err)
+	}
+	date := time.Date(2018, time.September, 20, 0, 0, 0, 0, loc)
+	at := func(h, m int) int { return millis(date.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)) }
+	return &oba.StopSchedule{
+		Date:     millis(date),
+		TimeZone: "America/Los_Angeles",
+		StopRouteSchedules: []oba.StopRouteSchedule{
+			{Route: oba.Route{ID: "1_100224", ShortName: "44"}, StopRouteDirectionSchedules: []oba.StopRouteDirectionSchedule{{
+				TripHeadsign: "Ballard",
+				ScheduleStopTimes: []oba.ScheduleStopTime{
+					{DepartureTime: at(8, 30), TripID: "t2"},
+					{DepartureTime: at(8, 0), TripID: "t1"},
+					{ArrivalTime: at(9, 0), TripID: "t3"},
+				},
+				ScheduleFrequencies: []oba.ScheduleFrequency{
+					{Frequency: oba.Frequency{StartTime: at(10, 0), EndTime: at(12, 0), Headway: 600}, TripID: "f1"},
+				},
+			}}},
+			{Route: oba.Route{ID: "1_100228"}, StopRouteDirectionSchedules: []oba.StopRouteDirectionSchedule{{
+				TripHeadsign: "Mount Baker",
+				ScheduleStopTimes: []oba.ScheduleStopTime{
+					{DepartureTime: at(8, 10), TripID: "t4"},
+				},
+			}}},
+		},
+	}, loc
+}
+
+func TestTimetable(t *testing.T) {
+	ss, loc := testStopSchedule(t)
+	day := time.Date(2018, time.September, 20, 0, 0, 0, 0, loc)
+
+	groups := Timetable(ss, nil, day.Add(8*time.Hour+15*time.Minute))
+	if !assert.Len(t, groups, 2) {
+		return
+	}
+	g := groups[0]
+	if assert.Len(t, g, 4) {
+		assert.Equal(t, []string{"t1", "t2", "t3", "f1"}, []string{g[0].TripID, g[1].TripID, g[2].TripID, g[3].TripID}, "ordered by time")
+		assert.Equal(t, "44", g[0].Route)
+		assert.Equal(t, "Ballard", g[0].Headsign)
+		assert.Equal(t, "08:00", g[0].Departs.Format("15:04"))
+		assert.Equal(t, "09:00", g[2].Departs.Format("15:04"), "arrival time without a departure")
+		assert.Equal(t, loc, g[0].Departs.Location())
+		if assert.NotNil(t, g[3].Until) {
+			assert.Equal(t, "10:00-12:00", departs(g[3]))
+		}
+		assert.Equal(t, 600, g[3].Every)
+	}
+	assert.Equal(t, "1_100228", groups[1][0].Route, "route id without a short name")
+
+	groups = Timetable(ss, []string{"1_100228"}, time.Time{})
+	if assert.Len(t, groups, 1) {
+		assert.Equal(t, "t4", groups[0][0].TripID)
+	}
+	assert.Len(t, Timetable(ss, []string{"44"}, time.Time{}), 1, "short names filter")
+}
+
+func TestTimetable_Next(t *testing.T) {
+	ss, loc := testStopSchedule(t)
+	day := time.Date(2018, time.September, 20, 0, 0, 0, 0, loc)
+	tests := []struct {
+		name string
+		at   time.Time
+		next string
+	}{
+		{"before the first", day.Add(7 * time.Hour), "t1"},
+		{"at a departure", day.Add(8*time.Hour + 30*time.Minute), "t2"},
+		{"between departures", day.Add(8*time.Hour + 15*time.Minute), "t2"},
+		{"in a frequency window", day.Add(11 * time.Hour), "f1"},
+		{"after the last", day.Add(13 * time.Hour), ""},
+		{"another day", day.Add(31 * time.Hour), ""},
+		{"zero time", time.Time{}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			groups := Timetable(ss, []string{"44"}, test.at)
+			if !assert.Len(t, groups, 1) {
+				return
+			}
+			next := ""
+			for _, e := range groups[0] {
+				if e.Next {
+					assert.Empty(t, next, "a single next departure")
+					next = e.TripID
+				}
+			}
+			assert.Equal(t, test.next, next)
+		})
+	}
+}
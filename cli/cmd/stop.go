@@ -1,9 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
-	"github.com/Setheck/oba"
 	"github.com/spf13/cobra"
 )
 
@@ -16,7 +13,7 @@ var stopCmd = &cobra.Command{
 	Short: "retrieve stops",
 	Long:  "get some stops",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := oba.NewDefaultClientS(baseUrl, apiKey)
+		client := newClient()
 		id, err := cmd.Flags().GetString("id")
 		if err != nil {
 			return err
@@ -25,7 +22,6 @@ var stopCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		fmt.Println(stop)
-		return nil
+		return output(cmd, stop)
 	},
 }
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// stty - run stty on the controlling terminal
+func stty(args ...string) (string, error) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return "", err
+	}
+	defer tty.Close()
+	c := exec.Command("stty", args...)
+	c.Stdin = tty
+	out, err := c.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// rawTerminal - read keys as they are typed without echo, the returned func
+// restores the terminal
+func rawTerminal() (func(), error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("the dashboard needs a terminal with stty: %v", err)
+	}
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		stty(saved)
+		return nil, err
+	}
+	return func() { stty(saved) }, nil
+}
+
+// terminalSize - rows and columns of the terminal, 24x80 when unknown
+func terminalSize() (int, int) {
+	out, err := stty("size")
+	if err == nil {
+		if f := strings.Fields(out); len(f) == 2 {
+			rows, rerr := strconv.Atoi(f[0])
+			cols, cerr := strconv.Atoi(f[1])
+			if rerr == nil && cerr == nil && rows > 0 && cols > 0 {
+				return rows, cols
+			}
+		}
+	}
+	return 24, 80
+}
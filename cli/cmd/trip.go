@@ -2,30 +2,228 @@ package cmd
 
 import (
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/Setheck/oba"
 	"github.com/spf13/cobra"
 )
 
+// progress marks of the stops of a trip
+const (
+	stopPassed  = "passed"
+	stopVehicle = "vehicle at stop"
+	stopNext    = "next"
+)
+
+// atStopMeters - how close along the trip the vehicle is to a stop to be at it
+const atStopMeters = 30
+
+// TripProgress - the itinerary of a trip with the realtime position of its
+// vehicle and the situations affecting it
+type TripProgress struct {
+	Trip        oba.Trip
+	ServiceDate int
+	Status      *oba.TripStatus `json:",omitempty"`
+	Stops       []TripStop
+	Situations  []oba.Situation
+}
+
+// TripStop - a stop of a trip itinerary, Predicted is the scheduled time moved
+// by the schedule deviation of the vehicle for the stops still ahead
+type TripStop struct {
+	Sequence  int
+	Stop      oba.Stop
+	Distance  float64
+	Scheduled time.Time
+	Predicted *time.Time `json:",omitempty"`
+	Progress  string     `json:",omitempty"`
+}
+
 func init() {
 	tripCmd.Flags().String("id", "", "trip id for lookup")
+	tripCmd.Flags().Bool("details", false, "show the stop by stop progress of the trip")
+
+	registerColumns(TripStop{},
+		Column{"seq", func(v interface{}) string { return strconv.Itoa(v.(TripStop).Sequence) }},
+		Column{"stop", func(v interface{}) string { return v.(TripStop).Stop.ID }},
+		Column{"name", func(v interface{}) string { return v.(TripStop).Stop.Name }},
+		Column{"scheduled", func(v interface{}) string { return v.(TripStop).Scheduled.Format("15:04") }},
+		Column{"predicted", func(v interface{}) string { return predicted(v.(TripStop)) }},
+		Column{"progress", func(v interface{}) string { return v.(TripStop).Progress }},
+	)
 }
 
 var tripCmd = &cobra.Command{
-	Use:   "trip",
+	Use:   "trip [id]",
 	Short: "retrieve trips",
-	Long:  "get some trips",
+	Long:  "get a trip, with --details its stops in order marking the progress and predicted times of the vehicle",
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		client := oba.NewDefaultClientS(baseUrl, apiKey)
+		client := newClient()
 		id, err := cmd.Flags().GetString("id")
 		if err != nil {
 			return err
 		}
+		if len(args) > 0 {
+			id = args[0]
+		}
+		if id == "" {
+			return fmt.Errorf("trip id is required")
+		}
+		details, err := cmd.Flags().GetBool("details")
+		if err != nil {
+			return err
+		}
+		if details {
+			return TripDetails(cmd, client, id)
+		}
 		trip, err := client.Trip(id)
 		if err != nil {
 			return err
 		}
-		fmt.Println(trip)
-		return nil
+		return output(cmd, trip)
 	},
 }
+
+// TripDetails - print the progress of trip id, in the table format as a
+// summary, the itinerary and the service alerts
+func TripDetails(cmd *cobra.Command, client oba.Client, id string) error {
+	td, err := client.TripDetails(id)
+	if err != nil {
+		return err
+	}
+	if td.Schedule == nil {
+		return fmt.Errorf("no schedule for trip %s", id)
+	}
+	tp := Progress(td)
+	switch {
+	case outputFormat == formatTable:
+		return printProgress(cmd, td, tp)
+	case outputFormat == formatCSV || len(outputFields) > 0:
+		return output(cmd, tp.Stops)
+	}
+	return output(cmd, tp)
+}
+
+func printProgress(cmd *cobra.Command, td *oba.TripDetails, tp *TripProgress) error {
+	w := cmd.OutOrStdout()
+	if err := output(cmd, *td); err != nil {
+		return err
+	}
+	if s := tp.Status; s != nil && s.VehicleID != "" {
+		fmt.Fprintf(w, "\nvehicle %s at %s,%s%s\n",
+			s.VehicleID, coord(s.Position.Lat), coord(s.Position.Lon), vehiclePlace(tp.Stops, s.DistanceAlongTrip))
+	}
+	fmt.Fprintln(w)
+	if err := output(cmd, tp.Stops); err != nil {
+		return err
+	}
+	if len(tp.Situations) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "\nService alerts")
+	return output(cmd, tp.Situations)
+}
+
+// Progress - the itinerary of td with the stops the vehicle passed, is at and
+// reaches next marked, and the situations its status names
+func Progress(td *oba.TripDetails) *TripProgress {
+	tp := &TripProgress{
+		Trip:        td.Trip,
+		ServiceDate: td.ServiceDate,
+		Status:      td.TripStatus,
+	}
+	// the references hold situations of other trips too, only those the
+	// status names affect this one
+	if s := td.TripStatus; s != nil {
+		active := make(map[string]bool, len(s.SituationIDs))
+		for _, sid := range s.SituationIDs {
+			active[sid] = true
+		}
+		for _, sit := range td.Situations {
+			if active[sit.ID] {
+				tp.Situations = append(tp.Situations, sit)
+			}
+		}
+	}
+	loc := time.Local
+	if tz := td.Schedule.TimeZone; tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+	start := oba.MillisToTime(td.ServiceDate).In(loc)
+	for i, st := range td.Schedule.StopTimes {
+		tp.Stops = append(tp.Stops, TripStop{
+			Sequence:  i + 1,
+			Stop:      st.Stop,
+			Distance:  st.DistanceAlongTrip,
+			Scheduled: start.Add(time.Duration(st.ArrivalTime) * time.Second),
+		})
+	}
+
+	s := td.TripStatus
+	if s == nil || s.LastUpdateTime == 0 {
+		return tp
+	}
+
+	next := -1
+	for i, ts := range tp.Stops {
+		if ts.Stop.ID == s.NextStop.ID {
+			next = i
+			break
+		}
+	}
+	deviation := time.Duration(s.ScheduleDeviation) * time.Second
+	for i := range tp.Stops {
+		ts := &tp.Stops[i]
+		// the distances place the vehicle between stops, without them the
+		// stops before the next one are passed
+		switch {
+		case s.DistanceAlongTrip > 0 && ts.Distance > 0 && abs(ts.Distance-s.DistanceAlongTrip) <= atStopMeters:
+			ts.Progress = stopVehicle
+		case i == next:
+			ts.Progress = stopNext
+		case s.DistanceAlongTrip > 0 && ts.Distance > 0 && ts.Distance < s.DistanceAlongTrip, next >= 0 && i < next:
+			ts.Progress = stopPassed
+		}
+		if ts.Progress != stopPassed {
+			p := ts.Scheduled.Add(deviation)
+			ts.Predicted = &p
+		}
+	}
+	return tp
+}
+
+// vehiclePlace - where the vehicle is relative to the stops, at one or past the
+// last one passed, empty without a distance along the trip
+func vehiclePlace(stops []TripStop, distance float64) string {
+	if distance <= 0 {
+		return ""
+	}
+	place := ""
+	for _, ts := range stops {
+		switch ts.Progress {
+		case stopVehicle:
+			return ", at " + ts.Stop.Name
+		case stopPassed:
+			place = fmt.Sprintf(", %s past %s", meters(distance-ts.Distance), ts.Stop.Name)
+		}
+	}
+	return place
+}
+
+func predicted(ts TripStop) string {
+	if ts.Predicted == nil {
+		return ""
+	}
+	return ts.Predicted.Format("15:04")
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
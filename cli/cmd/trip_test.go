@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+// testTripDetails - five stops two minutes and 1000m apart from 10:00, the
+// vehicle is 3m late between the third and fourth stop
+func testTripDetails() *oba.TripDetails {
+	date := time.Date(2018, time.September, 20, 0, 0, 0, 0, time.UTC)
+	td := &oba.TripDetails{
+		Trip:        oba.Trip{ID: "t1", RouteID: "1_100224"},
+		ServiceDate: millis(date),
+		Schedule:    &oba.TripSchedule{TimeZone: "UTC"},
+		TripStatus: &oba.TripStatus{
+			DistanceAlongTrip: 2400,
+			LastUpdateTime:    millis(date.Add(10 * time.Hour)),
+			NextStop:          oba.Stop{ID: "s4"},
+			ScheduleDeviation: 180,
+			SituationIDs:      []string{"alert"},
+		},
+		Situations: []oba.Situation{{ID: "other"}, {ID: "alert"}},
+	}
+	for i, id := range []string{"s1", "s2", "s3", "s4", "s5"} {
+		td.Schedule.StopTimes = append(td.Schedule.StopTimes, oba.TripStopTime{
+			ArrivalTime:       36000 + i*120,
+			DistanceAlongTrip: float64(i * 1000),
+			Stop:              oba.Stop{ID: id, Name: "Stop " + id},
+		})
+	}
+	return td
+}
+
+func TestProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		distance float64
+		progress []string
+		place    string
+	}{
+		{"between stops", 2400, []string{stopPassed, stopPassed, stopPassed, stopNext, ""}, ", 400m past Stop s3"},
+		{"at a stop", 2010, []string{stopPassed, stopPassed, stopVehicle, stopNext, ""}, ", at Stop s3"},
+		{"without distances", 0, []string{stopPassed, stopPassed, stopPassed, stopNext, ""}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			td := testTripDetails()
+			td.TripStatus.DistanceAlongTrip = test.distance
+			tp := Progress(td)
+			if !assert.Len(t, tp.Stops, 5) {
+				return
+			}
+			var progress []string
+			for _, ts := range tp.Stops {
+				progress = append(progress, ts.Progress)
+			}
+			assert.Equal(t, test.progress, progress)
+			assert.Equal(t, "10:06", tp.Stops[3].Scheduled.Format("15:04"))
+			assert.Nil(t, tp.Stops[0].Predicted, "passed stops have no prediction")
+			assert.Equal(t, "10:09", predicted(tp.Stops[3]), "scheduled time moved by the deviation")
+			assert.Equal(t, test.place, vehiclePlace(tp.Stops, test.distance))
+			if assert.Len(t, tp.Situations, 1) {
+				assert.Equal(t, "alert", tp.Situations[0].ID)
+			}
+		})
+	}
+}
+
+func TestProgress_WithoutStatus(t *testing.T) {
+	td := testTripDetails()
+	td.TripStatus.SituationIDs = nil
+	tp := Progress(td)
+	assert.Empty(t, tp.Situations, "only the situations the status names")
+
+	td.TripStatus = nil
+	tp = Progress(td)
+	for _, ts := range tp.Stops {
+		assert.Empty(t, ts.Progress)
+		assert.Nil(t, ts.Predicted)
+	}
+}
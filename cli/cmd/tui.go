@@ -0,0 +1,382 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+)
+
+// terminal control sequences
+const (
+	altScreenOn  = "\033[?1049h"
+	altScreenOff = "\033[?1049l"
+	cursorHide   = "\033[?25l"
+	cursorShow   = "\033[?25h"
+	reverseOn    = "\033[7m"
+	styleOff     = "\033[0m"
+)
+
+// keys - the keys the dashboard reacts to
+const (
+	keyQuit = iota
+	keyRefresh
+	keyNext
+	keyPrev
+	keyUp
+	keyDown
+)
+
+func init() {
+	tuiCmd.Flags().StringSlice("stop", nil, "stop ids to show arrivals for")
+	tuiCmd.Flags().String("route", "", "route id to show vehicles and alerts for")
+	tuiCmd.Flags().String("agency", "", "agency of --route, taken from the route id when empty")
+	tuiCmd.Flags().Duration("interval", 30*time.Second, "refresh interval")
+	rootCmd.AddCommand(tuiCmd)
+}
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "full screen dashboard",
+	Long: "monitor the arrivals at stops, the vehicles of a route and active service alerts. " +
+		"Keys: tab or arrows left/right change pane, up/down or j/k scroll, r refreshes, q quits",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// while the dashboard runs log messages would scroll the screen, the
+		// client is built after so it logs nothing either
+		saved := logger
+		logger = oba.NopLogger
+		defer func() { logger = saved }()
+		client := newClient()
+
+		stops, err := cmd.Flags().GetStringSlice("stop")
+		if err != nil {
+			return err
+		}
+		route, err := cmd.Flags().GetString("route")
+		if err != nil {
+			return err
+		}
+		agency, err := cmd.Flags().GetString("agency")
+		if err != nil {
+			return err
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		if len(stops) == 0 && route == "" {
+			return fmt.Errorf("--stop or --route is required")
+		}
+		if interval <= 0 {
+			return fmt.Errorf("--interval must be positive")
+		}
+		if agency == "" && route != "" {
+			agency = strings.SplitN(route, "_", 2)[0]
+		}
+		d := &dashboard{client: client, stops: stops, route: route, agency: agency}
+		return d.run(os.Stdout, interval)
+	},
+}
+
+// pane - a titled table of the dashboard
+type pane struct {
+	title  string
+	lines  []string
+	offset int
+}
+
+// dashboard - the state of the tui
+type dashboard struct {
+	client  oba.Client
+	stops   []string
+	route   string
+	agency  string
+	panes   []*pane
+	focus   int
+	updated time.Time
+	status  string
+	rows    int
+	cols    int
+	// fetching - a fetch is running, refreshes are skipped until it
+	// returns so an older result never replaces a newer one
+	fetching bool
+}
+
+// run - show the dashboard on the terminal until quit
+func (d *dashboard) run(out io.Writer, interval time.Duration) error {
+	restore, err := rawTerminal()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	fmt.Fprint(out, altScreenOn, cursorHide)
+	defer fmt.Fprint(out, cursorShow, altScreenOff)
+
+	keys := make(chan int)
+	go readKeys(os.Stdin, keys)
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	results := make(chan []*pane, 1)
+	refresh := func() {
+		// a resized terminal is picked up with each refresh rather than on
+		// every redraw
+		d.rows, d.cols = terminalSize()
+		if d.fetching {
+			return
+		}
+		d.fetching = true
+		d.status = "refreshing..."
+		go func() { results <- d.fetch() }()
+	}
+	refresh()
+	for {
+		d.render(out)
+		select {
+		case <-interrupt:
+			return nil
+		case <-ticker.C:
+			refresh()
+		case panes := <-results:
+			d.fetching = false
+			d.update(panes)
+		case k, ok := <-keys:
+			if !ok || k == keyQuit {
+				return nil
+			}
+			d.key(k, refresh)
+		}
+	}
+}
+
+func (d *dashboard) key(k int, refresh func()) {
+	if len(d.panes) == 0 {
+		if k == keyRefresh {
+			refresh()
+		}
+		return
+	}
+	p := d.panes[d.focus]
+	switch k {
+	case keyRefresh:
+		refresh()
+	case keyNext:
+		d.focus = (d.focus + 1) % len(d.panes)
+	case keyPrev:
+		d.focus = (d.focus + len(d.panes) - 1) % len(d.panes)
+	case keyUp:
+		if p.offset > 0 {
+			p.offset--
+		}
+	case keyDown:
+		if p.offset < len(p.lines)-2 {
+			p.offset++
+		}
+	}
+}
+
+// update - replace the panes keeping the scroll position of panes with the
+// same title
+func (d *dashboard) update(panes []*pane) {
+	offsets := make(map[string]int, len(d.panes))
+	for _, p := range d.panes {
+		offsets[p.title] = p.offset
+	}
+	for _, p := range panes {
+		p.offset = offsets[p.title]
+		if p.offset > len(p.lines)-2 {
+			p.offset = 0
+		}
+	}
+	d.panes = panes
+	if d.focus >= len(panes) {
+		d.focus = 0
+	}
+	d.updated = now()
+	d.status = ""
+}
+
+// fetch - the panes of the dashboard from fresh requests, a failed request
+// shows its error in the pane
+func (d *dashboard) fetch() []*pane {
+	var panes []*pane
+	situations := make(map[string]oba.Situation)
+	for _, id := range d.stops {
+		p := &pane{title: "Arrivals at " + id}
+		sad, err := d.client.ArrivalsAndDeparturesForStop(id, map[string]string{"minutesBefore": "0", "minutesAfter": "60"})
+		if err != nil {
+			p.lines = []string{"error: " + err.Error()}
+		} else {
+			ads := sad.ArrivalsAndDepartures
+			sort.SliceStable(ads, func(i, j int) bool {
+				return arrivalTime(ads[i]) < arrivalTime(ads[j])
+			})
+			p.lines = table(ads)
+			for _, s := range sad.Situations {
+				situations[s.ID] = s
+			}
+		}
+		panes = append(panes, p)
+	}
+
+	if d.route != "" {
+		p := &pane{title: "Vehicles on " + d.route}
+		vs, err := d.client.VehiclesForAgency(d.agency)
+		if err != nil {
+			p.lines = []string{"error: " + err.Error()}
+		} else {
+			vs = filterVehicles(vs, []string{d.route})
+			sort.SliceStable(vs, func(i, j int) bool {
+				return vs[i].TripStatus.ScheduleDeviation > vs[j].TripStatus.ScheduleDeviation
+			})
+			p.lines = table(vs)
+		}
+		panes = append(panes, p)
+
+		tds, err := d.client.TripsForRoute(d.route)
+		if err == nil {
+			for _, td := range tds {
+				for _, s := range td.Situations {
+					situations[s.ID] = s
+				}
+			}
+		}
+	}
+
+	alerts := make([]oba.Situation, 0, len(situations))
+	for _, s := range situations {
+		alerts = append(alerts, s)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].ID < alerts[j].ID })
+	p := &pane{title: "Service alerts", lines: []string{"no active alerts"}}
+	if len(alerts) > 0 {
+		p.lines = table(alerts)
+	}
+	return append(panes, p)
+}
+
+// table - the lines of v in the table format with its default columns
+func table(v interface{}) []string {
+	var buf bytes.Buffer
+	p := &printer{w: &buf, format: formatTable}
+	if err := p.print(v); err != nil {
+		return []string{"error: " + err.Error()}
+	}
+	return strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+}
+
+// render - draw the dashboard, the panes share the rows under the status line
+// and keep their table header when scrolled
+func (d *dashboard) render(out io.Writer) {
+	rows, cols := d.rows, d.cols
+	var buf bytes.Buffer
+	buf.WriteString("\033[H\033[2J")
+
+	status := "oba tui"
+	if !d.updated.IsZero() {
+		status += "  updated " + d.updated.Format("15:04:05")
+	}
+	if d.status != "" {
+		status += "  " + d.status
+	}
+	status += "  [tab] pane  [up/down] scroll  [r] refresh  [q] quit"
+	buf.WriteString(fit(status, cols))
+
+	if n := len(d.panes); n > 0 {
+		height := (rows - 1) / n
+		for i, p := range d.panes {
+			title := fit(fmt.Sprintf(" %s ", p.title), cols)
+			if i == d.focus {
+				title = reverseOn + title + styleOff
+			}
+			buf.WriteString("\r\n" + title)
+			shown := 0
+			for j, line := range p.lines {
+				if shown >= height-1 {
+					break
+				}
+				if j > 0 && j <= p.offset {
+					continue
+				}
+				buf.WriteString("\r\n" + fit(line, cols))
+				shown++
+			}
+			for ; shown < height-1; shown++ {
+				buf.WriteString("\r\n")
+			}
+		}
+	}
+	out.Write(buf.Bytes())
+}
+
+// fit - s cut to at most width runes
+func fit(s string, width int) string {
+	r := []rune(s)
+	if width > 0 && len(r) > width {
+		return string(r[:width])
+	}
+	return s
+}
+
+// readKeys - send the dashboard keys read from r, closing keys at the end of
+// input
+func readKeys(r io.Reader, keys chan<- int) {
+	defer close(keys)
+	buf := make([]byte, 16)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// parseKeys - the keys in a read from the terminal, arrows arrive as escape
+// sequences
+func parseKeys(b []byte) []int {
+	var keys []int
+	for i := 0; i < len(b); i++ {
+		if b[i] == 0x1b && i+2 < len(b) && b[i+1] == '[' {
+			switch b[i+2] {
+			case 'A':
+				keys = append(keys, keyUp)
+			case 'B':
+				keys = append(keys, keyDown)
+			case 'C':
+				keys = append(keys, keyNext)
+			case 'D', 'Z':
+				keys = append(keys, keyPrev)
+			}
+			i += 2
+			continue
+		}
+		switch b[i] {
+		case 'q', 'Q', 0x03:
+			keys = append(keys, keyQuit)
+		case 'r', 'R':
+			keys = append(keys, keyRefresh)
+		case '\t', 'l':
+			keys = append(keys, keyNext)
+		case 'h':
+			keys = append(keys, keyPrev)
+		case 'k':
+			keys = append(keys, keyUp)
+		case 'j':
+			keys = append(keys, keyDown)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []int
+	}{
+		{"letters", "qrjk", []int{keyQuit, keyRefresh, keyDown, keyUp}},
+		{"pane keys", "\thl", []int{keyNext, keyPrev, keyNext}},
+		{"ctrl-c", "\x03", []int{keyQuit}},
+		{"arrows", "\x1b[A\x1b[B\x1b[C\x1b[D", []int{keyUp, keyDown, keyNext, keyPrev}},
+		{"shift tab", "\x1b[Z", []int{keyPrev}},
+		{"arrow then letter", "\x1b[Bq", []int{keyDown, keyQuit}},
+		{"partial escape", "\x1b[", nil},
+		{"other keys", "xyz \x1b[H", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, parseKeys([]byte(test.in)))
+		})
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"arrivals", 20, "arrivals"},
+		{"arrivals", 6, "arriva"},
+		{"arrivals", 0, "arrivals"},
+		{"café au lait", 4, "café"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, fit(test.s, test.width), "%q %d", test.s, test.width)
+	}
+}
+
+// testPanes - panes with the given number of lines each, the first line the
+// table header
+func testPanes(lines ...int) []*pane {
+	var panes []*pane
+	for i, n := range lines {
+		panes = append(panes, &pane{title: string(rune('a' + i)), lines: make([]string, n)})
+	}
+	return panes
+}
+
+func TestDashboard_Key(t *testing.T) {
+	tests := []struct {
+		name      string
+		focus     int
+		offset    int
+		keys      []int
+		focused   int
+		offsets   []int
+		refreshed int
+	}{
+		{"next pane", 0, 0, []int{keyNext}, 1, []int{0, 0}, 0},
+		{"next pane wraps", 1, 0, []int{keyNext}, 0, []int{0, 0}, 0},
+		{"previous pane wraps", 0, 0, []int{keyPrev}, 1, []int{0, 0}, 0},
+		{"scroll down", 0, 0, []int{keyDown, keyDown}, 0, []int{2, 0}, 0},
+		{"scroll stops at the last line", 0, 2, []int{keyDown, keyDown, keyDown}, 0, []int{3, 0}, 0},
+		{"scroll up stops at the top", 0, 1, []int{keyUp, keyUp}, 0, []int{0, 0}, 0},
+		{"scroll the focused pane", 1, 0, []int{keyDown}, 1, []int{0, 0}, 0},
+		{"refresh", 0, 0, []int{keyRefresh, keyRefresh}, 0, []int{0, 0}, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &dashboard{panes: testPanes(5, 1), focus: test.focus}
+			d.panes[0].offset = test.offset
+			refreshed := 0
+			for _, k := range test.keys {
+				d.key(k, func() { refreshed++ })
+			}
+			assert.Equal(t, test.focused, d.focus, "focus")
+			assert.Equal(t, test.offsets, []int{d.panes[0].offset, d.panes[1].offset}, "offsets")
+			assert.Equal(t, test.refreshed, refreshed, "refreshes")
+		})
+	}
+}
+
+func TestDashboard_KeyWithoutPanes(t *testing.T) {
+	d := &dashboard{}
+	refreshed := 0
+	for _, k := range []int{keyNext, keyDown, keyRefresh} {
+		d.key(k, func() { refreshed++ })
+	}
+	assert.Equal(t, 0, d.focus)
+	assert.Equal(t, 1, refreshed, "only refresh works before the first fetch")
+}
+
+func TestDashboard_Update(t *testing.T) {
+	tests := []struct {
+		name    string
+		focus   int
+		offset  int
+		panes   []*pane
+		focused int
+		want    int
+	}{
+		{"keeps the offset", 1, 2, testPanes(3, 6), 1, 2},
+		{"offset past the lines", 1, 2, testPanes(3, 3), 1, 0},
+		{"focus on a removed pane", 2, 2, testPanes(3, 6), 0, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &dashboard{panes: testPanes(3, 6, 2), focus: test.focus, status: "refreshing..."}
+			d.panes[1].offset = test.offset
+			d.update(test.panes)
+			assert.Equal(t, test.focused, d.focus, "focus")
+			assert.Equal(t, test.want, d.panes[1].offset, "offset of pane b")
+			assert.Equal(t, 0, d.panes[0].offset, "offset of pane a")
+			assert.Empty(t, d.status)
+			assert.False(t, d.updated.IsZero())
+		})
+	}
+}
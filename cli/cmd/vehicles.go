@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	vehiclesCmd.Flags().String("agency", "", "agency id")
+	vehiclesCmd.Flags().StringSlice("route", nil, "only show vehicles on these route ids or short names")
+	rootCmd.AddCommand(vehiclesCmd)
+
+	vehicleCmd.Flags().Bool("watch", false, "follow the vehicle until interrupted, as a table unless --output is given")
+	vehicleCmd.Flags().Duration("interval", 15*time.Second, "refresh interval of --watch")
+	rootCmd.AddCommand(vehicleCmd)
+}
+
+var vehiclesCmd = &cobra.Command{
+	Use:   "vehicles",
+	Short: "vehicles of an agency",
+	Long:  "list the active vehicles of an agency with their position and schedule deviation",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		id, err := cmd.Flags().GetString("agency")
+		if err != nil {
+			return err
+		}
+		if id == "" {
+			return fmt.Errorf("--agency is required")
+		}
+		routes, err := cmd.Flags().GetStringSlice("route")
+		if err != nil {
+			return err
+		}
+		return Vehicles(cmd, client, id, routes)
+	},
+}
+
+var vehicleCmd = &cobra.Command{
+	Use:   "vehicle <id>",
+	Short: "follow a vehicle",
+	Long:  "show the current trip of a vehicle with its next stop and schedule deviation",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		client := newClient()
+		id := args[0]
+		show := func() error {
+			td, err := client.TripForVehicle(id, nil)
+			if err != nil {
+				return err
+			}
+			return output(cmd, td)
+		}
+
+		watch, err := cmd.Flags().GetBool("watch")
+		if err != nil {
+			return err
+		}
+		if !watch {
+			return show()
+		}
+		interval, err := cmd.Flags().GetDuration("interval")
+		if err != nil {
+			return err
+		}
+		watchTable(cmd)
+		return watchEvery(interval, func() error {
+			if outputFormat == formatTable {
+				fmt.Fprint(cmd.OutOrStdout(), clearScreen)
+				fmt.Fprintf(cmd.OutOrStdout(), "Vehicle %s, updated %s\n\n", id, now().Format("15:04:05"))
+			}
+			return show()
+		})
+	},
+}
+
+// Vehicles - print the vehicles of agency id on any of routes, all when empty,
+// ordered by route and vehicle
+func Vehicles(cmd *cobra.Command, client oba.Client, id string, routes []string) error {
+	vs, err := client.VehiclesForAgency(id)
+	if err != nil {
+		return err
+	}
+	vs = filterVehicles(vs, routes)
+	sort.SliceStable(vs, func(i, j int) bool {
+		a, b := vs[i], vs[j]
+		if a.Trip.RouteID != b.Trip.RouteID {
+			return a.Trip.RouteID < b.Trip.RouteID
+		}
+		return a.VehicleID < b.VehicleID
+	})
+	return output(cmd, vs)
+}
+
+func filterVehicles(vs []oba.VehicleStatus, routes []string) []oba.VehicleStatus {
+	if len(routes) == 0 {
+		return vs
+	}
+	want := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		want[r] = true
+	}
+	var kept []oba.VehicleStatus
+	for _, v := range vs {
+		if want[v.Trip.RouteID] || want[v.Trip.RouteShortName] {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+)
+
+func testVehicles() []oba.VehicleStatus {
+	return []oba.VehicleStatus{
+		{VehicleID: "1_4361", Trip: oba.Trip{RouteID: "1_100228", RouteShortName: "48"}},
+		{VehicleID: "1_4302", Trip: oba.Trip{RouteID: "1_100224", RouteShortName: "44"}},
+		{VehicleID: "1_4388", Trip: oba.Trip{RouteID: "1_100228", RouteShortName: "48"}},
+		{VehicleID: "1_4010", Trip: oba.Trip{RouteID: "1_100224", RouteShortName: "44"}},
+		{VehicleID: "1_7001"},
+	}
+}
+
+func vehicleIDs(vs []oba.VehicleStatus) []string {
+	var ids []string
+	for _, v := range vs {
+		ids = append(ids, v.VehicleID)
+	}
+	return ids
+}
+
+func TestFilterVehicles(t *testing.T) {
+	tests := []struct {
+		name   string
+		routes []string
+		want   []string
+	}{
+		{"all routes", nil, []string{"1_4361", "1_4302", "1_4388", "1_4010", "1_7001"}},
+		{"route id", []string{"1_100224"}, []string{"1_4302", "1_4010"}},
+		{"short name", []string{"48"}, []string{"1_4361", "1_4388"}},
+		{"no match", []string{"8"}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, vehicleIDs(filterVehicles(testVehicles(), test.routes)))
+		})
+	}
+}
+
+func TestVehicles(t *testing.T) {
+	client := &fakeClient{vehicles: map[string][]oba.VehicleStatus{"1": testVehicles()}}
+	tests := []struct {
+		name   string
+		agency string
+		routes []string
+		want   []string
+		err    bool
+	}{
+		{"by route then vehicle", "1", nil, []string{"1_7001", "1_4010", "1_4302", "1_4361", "1_4388"}, false},
+		{"filtered", "1", []string{"48"}, []string{"1_4361", "1_4388"}, false},
+		{"request failed", "40", nil, nil, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var vs []oba.VehicleStatus
+			err := runOutput(t, &vs, func(cmd *cobra.Command) error {
+				return Vehicles(cmd, client, test.agency, test.routes)
+			})
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.want, vehicleIDs(vs))
+		})
+	}
+}
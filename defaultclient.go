@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"time"
 )
 
 const (
+	dateFormat                                        = "2006-01-02"
 	jsonPostFix                                       = ".json"
 	agencyEndPoint                                    = "agency/"
 	blockEndPoint                                     = "block/"
@@ -26,7 +28,10 @@ const (
 	reportPoblemWithTripEndPoint                      = "report-problem-with-trip/"
 	routeForAgencyEndPoint                            = "routes-for-agency/"
 	routeForLocationEndPoint                          = "routes-for-location"
+	scheduleForRouteEndPoint                          = "schedule-for-route/"
 	scheduleForStopEndPoint                           = "schedule-for-stop/"
+	searchRouteEndPoint                               = "search/route"
+	searchStopEndPoint                                = "search/stop"
 	stopIDsForAgencyEndPoint                          = "stop-ids-for-agency/"
 	stopsForLocationEndPoint                          = "stops-for-location"
 	stopsForRouteEndPoint                             = "stops-for-route/"
@@ -39,8 +44,13 @@ const (
 )
 
 type DefaultClient struct {
-	baseURL *url.URL
-	apiKey  string
+	baseURL      *url.URL
+	apiKey       string
+	batchWorkers int
+	limiter      *rateLimiter
+	observer     Observer
+	middleware   []Middleware
+	logger       Logger
 }
 
 // NewDefaultClient - instantiate a new instance of a Client
@@ -54,6 +64,23 @@ func NewDefaultClientS(s string, apiKey string) *DefaultClient {
 	return dc
 }
 
+// SetRateLimit - limit the client to at most perSecond requests per second,
+// shared by every copy of the client and all batch workers. Zero removes the
+// limit.
+func (c *DefaultClient) SetRateLimit(perSecond float64) {
+	if perSecond <= 0 {
+		c.limiter = nil
+		return
+	}
+	c.limiter = newRateLimiter(time.Duration(float64(time.Second) / perSecond))
+}
+
+// SetBatchWorkers - the number of concurrent requests made by the batch
+// methods Stops, Routes and Trips.
+func (c *DefaultClient) SetBatchWorkers(n int) {
+	c.batchWorkers = n
+}
+
 func (c *DefaultClient) setBaseURL(b string) {
 	u, e := url.Parse(b)
 	if e != nil {
@@ -292,6 +319,7 @@ func (c DefaultClient) ArrivalsAndDeparturesForStop(id string, params map[string
 			aads = data.Entry.ArrivalsAndDepartures.toArrivalAndDepartures(situations, stops, trips)
 		}
 		swaad = data.Entry.ToStopWithArrivalsAndDepartures(aads)
+		swaad.Situations = situations
 	}
 	return swaad, nil
 }
@@ -366,7 +394,7 @@ func (c DefaultClient) Block(id string) (*Block, error) {
 //
 func (c DefaultClient) CancelAlarm(id string) error {
 	u := c.buildRequestURL(fmt.Sprint(cancelAlarmEndPoint, id), nil)
-	_, err := requestAndHandle(u, "Failed to Cancel Alarm for ID: ")
+	_, err := c.requestAndHandle(u, "Failed to Cancel Alarm for ID: ")
 	return err
 }
 
@@ -601,7 +629,7 @@ func (c DefaultClient) ReportProblemWithTrip(id string, params map[string]string
 //
 func (c DefaultClient) RouteIdsForAgency(id string) ([]string, error) {
 	u := c.buildRequestURL(fmt.Sprint(routeIdsForAgencyEndPoint, id), nil)
-	response, err := requestAndHandleAlt(u, "RouteIdsForAgency")
+	response, err := c.requestAndHandleAlt(u, "RouteIdsForAgency")
 	if err != nil {
 		return nil, err
 	}
@@ -767,10 +795,85 @@ func (c DefaultClient) RoutesForLocation(params map[string]string) ([]Route, err
 		return nil, err
 	}
 	agencies := data.References.Agencies.toAgencies()
-	routes := data.References.Routes.toRoutes(agencies)
+	routes := data.List.toRoutes(agencies)
 	return routes, nil
 }
 
+// ScheduleForRoute - 	get the full schedule for a route on a particular day
+// http://developer.onebusaway.org/modules/onebusaway-application-modules/current/api/where/methods/schedule-for-route.html
+//
+// Method: schedule-for-route
+//  Retrieve the full schedule for a route on a particular day
+//
+// Sample Request
+// http://api.pugetsound.onebusaway.org/api/where/schedule-for-route/1_100224.xml?key=TEST&date=2018-09-20
+//
+// Sample Response
+// <response>
+//   <version>2</version>
+//   <code>200</code>
+//   <text>OK</text>
+//   <currentTime>1270614730908</currentTime>
+//   <data class="entryWithReferences">
+//     <references>...</references>
+//     <entry class="routeSchedule">
+//       <routeId>1_100224</routeId>
+//       <scheduleDate>1537426800000</scheduleDate>
+//       <serviceIds>
+//         <string>1_28099</string>
+//       </serviceIds>
+//       <stopTripGroupings>
+//         <stopTripGrouping>
+//           <directionId>0</directionId>
+//           <tripHeadsigns>
+//             <string>Montlake</string>
+//           </tripHeadsigns>
+//           <stopIds>...</stopIds>
+//           <tripIds>...</tripIds>
+//           <tripsWithStopTimes>
+//             <tripWithStopTimes>
+//               <tripId>1_40550000</tripId>
+//               <stopTimes>
+//                 <scheduleStopTime>...</scheduleStopTime>
+//               </stopTimes>
+//             </tripWithStopTimes>
+//           </tripsWithStopTimes>
+//         </stopTripGrouping>
+//         <!-- More stopTripGrouping entries -->
+//       </stopTripGroupings>
+//     </entry>
+//   </data>
+// </response>
+//
+// Request Parameters
+// id - 	the route id to request the schedule for, encoded directly in the URL:
+// 			http://api.pugetsound.onebusaway.org/api/where/schedule-for-route/[ID GOES HERE].xml
+// date - 	The date for which you want to request a schedule of the format YYYY-MM-DD (optional, defaults to current date)
+//
+// Response
+// The schedule is split by direction of travel into <stopTripGrouping/>
+// elements. Each grouping lists the stops served in that direction in order,
+// the headsigns used, and every trip active on the service date along with
+// its <scheduleStopTime/> elements at each stop. The stop and trip ids can be
+// used to access the <stop/> and <trip/> elements in the <references/> section.
+//
+// A zero date requests the schedule for the current service date.
+func (c DefaultClient) ScheduleForRoute(id string, date time.Time) (*RouteSchedule, error) {
+	data, err := c.getData(fmt.Sprint(scheduleForRouteEndPoint, id), "Schedule for Route", dateParams(date))
+	if err != nil {
+		return nil, err
+	}
+	agencies := data.Agencies()
+	routes := data.Routes(agencies)
+	stops := data.Stops(routes)
+	trips := data.Trips()
+	var rs *RouteSchedule
+	if data.Entry != nil {
+		rs = data.Entry.ToRouteSchedule(routes, stops, trips)
+	}
+	return rs, nil
+}
+
 // ScheduleForStop - 	get the full schedule for a stop on a particular day
 // http://developer.onebusaway.org/modules/onebusaway-application-modules/current/api/where/methods/schedule-for-stop.html
 //
@@ -873,19 +976,115 @@ func (c DefaultClient) RoutesForLocation(params map[string]string) ([]Route, err
 // 				the <stop/> element in the <references/> section
 // timeZone - 	the time-zone the stop is located in
 //
-func (c DefaultClient) ScheduleForStop(id string) (*StopSchedule, error) {
-	data, err := c.getData(fmt.Sprint(scheduleForStopEndPoint, id), "Schedule for Stop", nil)
+// A zero date requests the schedule for the current service date.
+func (c DefaultClient) ScheduleForStop(id string, date time.Time) (*StopSchedule, error) {
+	data, err := c.getData(fmt.Sprint(scheduleForStopEndPoint, id), "Schedule for Stop", dateParams(date))
 	if err != nil {
 		return nil, err
 	}
-	agencies := data.References.Agencies.toAgencies()
-	routes := data.References.Routes.toRoutes(agencies)
-	stops := data.References.Stops.toStops(routes)
-	ss := data.Entry.ToStopSchedule(stops)
-	ss.StopRouteSchedules = data.Entry.StopRouteSchedules.toStopRouteSchedules(routes)
+	agencies := data.Agencies()
+	routes := data.Routes(agencies)
+	stops := data.Stops(routes)
+	var ss *StopSchedule
+	if data.Entry != nil {
+		ss = data.Entry.ToStopSchedule(routes, stops)
+	}
 	return ss, nil
 }
 
+// SearchRoute -	search for routes by name
+//
+// Method: search/route
+//  Search for routes whose short or long name matches the input text, such as
+//  a rider typing “44” or “Rapid Ride”. Only available on newer servers.
+//
+// Sample Request
+// http://api.pugetsound.onebusaway.org/api/where/search/route.xml?key=TEST&input=44
+//
+// Sample Response
+// <response>
+//   <version>2</version>
+//   <code>200</code>
+//   <text>OK</text>
+//   <currentTime>1270614730908</currentTime>
+//   <data class="listWithReferences">
+//     <references>...</references>
+//     <list>
+//       <route>...</route>
+//       <!-- More routes -->
+//     </list>
+//     <limitExceeded>false</limitExceeded>
+//   </data>
+// </response>
+//
+// Request Parameters
+// input - 		the text to search for, set from the input argument
+// maxCount - 	the maximum number of results to return (optional)
+//
+// Response
+// The list contents are <route/> elements, so see details about the various
+// properties of the <route/> element.
+//
+func (c DefaultClient) SearchRoute(input string, params map[string]string) ([]Route, error) {
+	data, err := c.getData(searchRouteEndPoint, "Search Route", searchParams(input, params))
+	if err != nil {
+		return nil, err
+	}
+	agencies := data.Agencies()
+	var routes []Route
+	if data.List != nil {
+		routes = data.List.toRoutes(agencies)
+	}
+	return routes, nil
+}
+
+// SearchStop -	search for stops by name or code
+//
+// Method: search/stop
+//  Search for stops whose name or code matches the input text, such as a rider
+//  typing “Pine St” or “75403”. Only available on newer servers.
+//
+// Sample Request
+// http://api.pugetsound.onebusaway.org/api/where/search/stop.xml?key=TEST&input=Pine%20St
+//
+// Sample Response
+// <response>
+//   <version>2</version>
+//   <code>200</code>
+//   <text>OK</text>
+//   <currentTime>1270614730908</currentTime>
+//   <data class="listWithReferences">
+//     <references>...</references>
+//     <list>
+//       <stop>...</stop>
+//       <!-- More stops -->
+//     </list>
+//     <limitExceeded>false</limitExceeded>
+//   </data>
+// </response>
+//
+// Request Parameters
+// input - 		the text to search for, set from the input argument
+// maxCount - 	the maximum number of results to return (optional)
+//
+// Response
+// The list contents are <stop/> elements, with the routes serving each stop
+// resolved from the <references/> section.
+//
+func (c DefaultClient) SearchStop(input string, params map[string]string) ([]Stop, error) {
+	data, err := c.getData(searchStopEndPoint, "Search Stop", searchParams(input, params))
+	if err != nil {
+		return nil, err
+	}
+	agencies := data.Agencies()
+	routes := data.Routes(agencies)
+	var stops []Stop
+	if data.List != nil {
+		stops = data.List.toStops(routes)
+	}
+	return stops, nil
+}
+
 // Shape -	get details for a specific shape (polyline drawn on a map)
 // http://developer.onebusaway.org/modules/onebusaway-application-modules/current/api/where/methods/shape.html
 //
@@ -978,7 +1177,7 @@ func (c DefaultClient) Shape(id string) (*Shape, error) {
 
 func (c DefaultClient) StopIDsForAgency(id string) ([]string, error) {
 	u := c.buildRequestURL(fmt.Sprint(stopIDsForAgencyEndPoint, id), nil)
-	response, err := requestAndHandleAlt(u, "Failed to get Stop IDs for Agency: ")
+	response, err := c.requestAndHandleAlt(u, "Failed to get Stop IDs for Agency: ")
 	if err != nil {
 		return nil, err
 	}
@@ -1524,13 +1723,31 @@ func (c DefaultClient) getEntry(requestString, requestType string, params map[st
 
 func (c DefaultClient) getResponse(requestString string, errMessage string, params map[string]string) (*Response, error) {
 	u := c.buildRequestURL(fmt.Sprint(requestString, jsonPostFix), params)
-	response, err := requestAndHandle(u, errMessage)
+	response, err := c.requestAndHandle(u, errMessage)
 	if err != nil {
 		return nil, err
 	}
 	return response, nil
 }
 
+// dateParams - request parameters selecting a service date, nil for the zero date
+func dateParams(date time.Time) map[string]string {
+	if date.IsZero() {
+		return nil
+	}
+	return map[string]string{"date": date.Format(dateFormat)}
+}
+
+// searchParams - copy of params with the search input set
+func searchParams(input string, params map[string]string) map[string]string {
+	sp := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		sp[k] = v
+	}
+	sp["input"] = input
+	return sp
+}
+
 func (c DefaultClient) buildRequestURL(endpoint string, params map[string]string) string {
 	u := *c.baseURL
 	u.Path = path.Join(u.Path, endpoint)
@@ -5,6 +5,7 @@ package oba_test
 import (
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/Setheck/oba"
 	"github.com/stretchr/testify/assert"
@@ -92,7 +93,7 @@ func TestDefaultClient_ArrivalAndDepartureForStop(t *testing.T) {
 	assert.NotNil(t, aad.ArrivalEnabled, "ArrivalEnabled")
 	assert.NotNil(t, aad.DepartureEnabled, "DepartureEnabled")
 	assert.NotZero(t, aad.ScheduledArrivalTime, "ScheduledArrivalTime")
-	assert.NotZero(t, aad.Frequency, "Frequency")
+	VerifyFrequency(t, aad.Frequency)
 	assert.NotZero(t, aad.Predicted, "Predicted")
 	assert.NotZero(t, aad.PredictedArrivalTime, "PredictedArrivalTime")
 	assert.NotZero(t, aad.PredictedDepartureTime, "PredictedDepartureTime")
@@ -260,11 +261,52 @@ func TestDefaultClient_RoutesForLocation(t *testing.T) {
 		t.Error(e)
 	}
 
+	assert.NotEmpty(t, routes, "Routes")
 	for _, r := range routes {
+		// light rail is route type 0, the zero value VerifyRoute rejects
+		if r.ID == "40_100479" {
+			assert.Equal(t, 0, r.Type, "Route - Type")
+			assert.Equal(t, "Link light rail", r.ShortName, "Route - ShortName")
+			VerifyAgency(t, &r.Agency)
+			continue
+		}
 		VerifyRoute(t, &r)
 	}
 }
 
+func TestDefaultClient_ScheduleForRoute(t *testing.T) {
+	contents := RetrieveTestJsonFileContent(t)
+	server := FakeServer(t, contents)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	rs, e := client.ScheduleForRoute(TestID, time.Time{})
+	if e != nil {
+		t.Error(e)
+	}
+
+	VerifyRouteSchedule(t, rs)
+	assert.Len(t, rs.StopTripGroupings, 2, "StopTripGroupings")
+}
+
+func TestDefaultClient_ScheduleForRouteDate(t *testing.T) {
+	contents := ReadFile(t, "schedule-for-route.json")
+	var query url.Values
+	server := QueryServer(t, contents, &query)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	date := time.Date(2018, time.September, 20, 0, 0, 0, 0, time.UTC)
+	_, e := client.ScheduleForRoute(TestID, date)
+	if e != nil {
+		t.Error(e)
+	}
+
+	assert.Equal(t, "2018-09-20", query.Get("date"), "date")
+}
+
 func TestDefaultClient_ScheduleForStop(t *testing.T) {
 	contents := RetrieveTestJsonFileContent(t)
 	server := FakeServer(t, contents)
@@ -272,13 +314,79 @@ func TestDefaultClient_ScheduleForStop(t *testing.T) {
 
 	client := oba.NewDefaultClientS(server.URL, TestApiKey)
 
-	ss, e := client.ScheduleForStop(TestID)
+	ss, e := client.ScheduleForStop(TestID, time.Time{})
 	if e != nil {
 		t.Error(e)
 	}
 
 	VerifyStopSchedule(t, ss)
+	assert.Equal(t, "1_75403", ss.Stop.ID, "Stop - ID")
+	assert.Len(t, ss.StopRouteSchedules, 14, "StopRouteSchedules")
+	assert.Len(t, ss.StopCalendarDays, 4, "StopCalendarDays")
+	sfs := ss.StopRouteSchedules[0].StopRouteDirectionSchedules[0].ScheduleFrequencies
+	if assert.Len(t, sfs, 1, "ScheduleFrequencies") {
+		assert.Equal(t, 600, sfs[0].Headway, "ScheduleFrequency - Headway")
+		assert.False(t, sfs[0].ExactTimes, "ScheduleFrequency - ExactTimes")
+	}
+}
+
+func TestDefaultClient_ScheduleForStopDate(t *testing.T) {
+	contents := ReadFile(t, "schedule-for-stop.json")
+	var query url.Values
+	server := QueryServer(t, contents, &query)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	date := time.Date(2018, time.September, 21, 0, 0, 0, 0, time.UTC)
+	_, e := client.ScheduleForStop(TestID, date)
+	if e != nil {
+		t.Error(e)
+	}
 
+	assert.Equal(t, "2018-09-21", query.Get("date"), "date")
+}
+
+func TestDefaultClient_SearchRoute(t *testing.T) {
+	contents := RetrieveTestJsonFileContent(t)
+	var query url.Values
+	server := QueryServer(t, contents, &query)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	routes, e := client.SearchRoute("44", TestParameters)
+	if e != nil {
+		t.Error(e)
+	}
+
+	assert.Equal(t, "44", query.Get("input"), "input")
+	assert.Equal(t, "two", query.Get("one"), "params")
+	assert.NotEmpty(t, routes, "Routes")
+	for _, r := range routes {
+		VerifyRoute(t, &r)
+	}
+}
+
+func TestDefaultClient_SearchStop(t *testing.T) {
+	contents := RetrieveTestJsonFileContent(t)
+	var query url.Values
+	server := QueryServer(t, contents, &query)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	stops, e := client.SearchStop("15th Ave NE", nil)
+	if e != nil {
+		t.Error(e)
+	}
+
+	assert.Equal(t, "15th Ave NE", query.Get("input"), "input")
+	assert.NotEmpty(t, stops, "Stops")
+	for _, s := range stops {
+		VerifyStop(t, &s)
+		assert.NotEmpty(t, s.Routes, "Stop - Routes")
+	}
 }
 
 func TestDefaultClient_Shape(t *testing.T) {
@@ -341,6 +449,24 @@ func TestDefaultClient_StopsForLocation(t *testing.T) {
 	assert.NotEmpty(t, stops, "Stops")
 }
 
+func TestDefaultClient_StopsForLocationQuery(t *testing.T) {
+	contents := ReadFile(t, "stops-for-location.json")
+	var query url.Values
+	server := QueryServer(t, contents, &query)
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	_, e := client.StopsForLocation(oba.LocationParams(47.653435, -122.305641, "75403"))
+	if e != nil {
+		t.Error(e)
+	}
+
+	assert.Equal(t, "47.653435", query.Get("lat"), "lat")
+	assert.Equal(t, "-122.305641", query.Get("lon"), "lon")
+	assert.Equal(t, "75403", query.Get("query"), "query")
+}
+
 func TestDefaultClient_StopsForRoute(t *testing.T) {
 	contents := RetrieveTestJsonFileContent(t)
 	server := FakeServer(t, contents)
@@ -385,6 +511,50 @@ func TestDefaultClient_TripForVehicle(t *testing.T) {
 	VerifyTripDetails(t, td)
 }
 
+func TestDefaultClient_TripForVehicleStatus(t *testing.T) {
+	server := FakeServer(t, ReadFile(t, "trip-for-vehicle-status.json"))
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	td, e := client.TripForVehicle("1_4361", nil)
+	if !assert.NoError(t, e) || !assert.NotNil(t, td.TripStatus) {
+		return
+	}
+	assert.Empty(t, td.Status)
+	assert.Equal(t, "1_4361", td.TripStatus.VehicleID)
+	assert.Equal(t, 180, td.TripStatus.ScheduleDeviation)
+	assert.Equal(t, "University Way NE & NE 42nd St", td.TripStatus.NextStop.Name)
+	assert.Equal(t, "1_10914", td.TripStatus.ClosestStop.ID)
+	assert.Equal(t, "44", td.Trip.RouteShortName)
+}
+
+func TestDefaultClient_TripDetailsSchedule(t *testing.T) {
+	server := FakeServer(t, ReadFile(t, "trip-details-schedule.json"))
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, TestApiKey)
+
+	td, e := client.TripDetails("1_12540399")
+	if !assert.NoError(t, e) || !assert.NotNil(t, td.Schedule) {
+		return
+	}
+	assert.Equal(t, "America/Los_Angeles", td.Schedule.TimeZone)
+	assert.Equal(t, "1_12540400", td.Schedule.NextTripID)
+	assert.Equal(t, "1_12540398", td.Schedule.PreviousTripID)
+	if assert.Len(t, td.Schedule.StopTimes, 5) {
+		st := td.Schedule.StopTimes[2]
+		assert.Equal(t, 36240, st.ArrivalTime)
+		assert.Equal(t, 36260, st.DepartureTime)
+		assert.Equal(t, 2600.2, st.DistanceAlongTrip)
+		assert.Equal(t, "University Way NE & NE 42nd St", st.Stop.Name)
+	}
+	assert.Equal(t, "1_10920", td.TripStatus.NextStop.ID)
+	if assert.Len(t, td.Situations, 1) {
+		assert.Equal(t, "1_situation", td.Situations[0].ID)
+	}
+}
+
 func TestDefaultClient_Trip(t *testing.T) {
 	contents := RetrieveTestJsonFileContent(t)
 	server := FakeServer(t, contents)
@@ -1,5 +1,10 @@
 package oba
 
+import (
+	"bytes"
+	"encoding/json"
+)
+
 // Entry container object
 type Entry struct {
 	AccumulatedSlackTime         float64          `json:"accumulatedSlackTime,omitempty"`
@@ -35,11 +40,14 @@ type Entry struct {
 	DistanceFromStop             float64          `json:"distanceFromStop,omitempty"`
 	DropOffType                  int              `json:"dropOffType,omitempty"`
 	Email                        string           `json:"email,omitempty"`
-	EndTime                      int              `json:"entTime,omitempty"`
+	EndTime                      int              `json:"endTime,omitempty"`
 	EnvironmentReason            string           `json:"environmentReason"`
+	ExactTimes                   int              `json:"exactTimes,omitempty"`
 	FareURL                      string           `json:"fareUrl,omitempty"`
-	Frequency                    *string          `json:"frequency,omitempty"`
+	Frequency                    *Entry           `json:"frequency,omitempty"`
+	Group                        string           `json:"group,omitempty"`
 	Headway                      int              `json:"headway,omitempty"`
+	HeadwaySecs                  int              `json:"headwaySecs,omitempty"`
 	ID                           string           `json:"id,omitempty"`
 	InactiveServiceID            []string         `json:"inactiveServiceIds,omitempty"`
 	Lang                         string           `json:"lang,omitempty"`
@@ -62,6 +70,7 @@ type Entry struct {
 	NearbyStopIds                []string         `json:"nearbyStopIds,omitempty"`
 	NextStop                     string           `json:"nextStop,omitempty"`
 	NextStopTimeOffset           int              `json:"nextStopTimeOffset,omitempty"`
+	NextTripID                   string           `json:"nextTripId,omitempty"`
 	NumberOfStopsAway            int              `json:"numberOfStopsAway,omitempty"`
 	Orientation                  float64          `json:"orientation,omitempty"`
 	Ordered                      *bool            `json:"ordered,omitempty"`
@@ -76,12 +85,14 @@ type Entry struct {
 	PredictedArrivalTime         int              `json:"predictedArrivalTime,omitempty"`
 	PredictedDepartureInterval   int              `json:"predictedDepartureInterval,omitempty"`
 	PredictedDepartureTime       int              `json:"predictedDepartureTime,omitempty"`
+	PreviousTripID               string           `json:"previousTripId,omitempty"`
 	PrivateService               *bool            `json:"privateService,omitempty"`
 	ReadableTime                 string           `json:"readableTime,omitempty"`
 	RouteID                      string           `json:"routeId,omitempty"`
 	RouteIDs                     []string         `json:"routeIds,omitempty"`
 	RouteLongName                string           `json:"routeLongName,omitempty"`
 	RouteShortName               string           `json:"routeShortName,omitempty"`
+	ScheduleDate                 int              `json:"scheduleDate,omitempty"`
 	ScheduledArrivalInterval     int              `json:"scheduledArrivalInterval,omitempty"`
 	ScheduledArrivalTime         int              `json:"scheduledArrivalTime,omitempty"`
 	ScheduledDepartureInterval   int              `json:"scheduledDepartureInterval,omitempty"`
@@ -93,6 +104,7 @@ type Entry struct {
 	ScheduleStopTimes            List             `json:"scheduleStopTimes,omitempty"`
 	ServiceDate                  int              `json:"serviceDate,omitempty"`
 	ServiceID                    string           `json:"serviceId,omitempty"`
+	ServiceIDs                   []string         `json:"serviceIds,omitempty"`
 	ShapeID                      string           `json:"shapeId,omitempty"`
 	ShortName                    string           `json:"shortName,omitempty"`
 	SituationID                  string           `json:"situationId,omitempty"`
@@ -109,6 +121,8 @@ type Entry struct {
 	StopRouteDirectionSchedules  List             `json:"stopRouteDirectionSchedules,omitempty"`
 	StopSequence                 int              `json:"stopSequence,omitempty"`
 	StopTime                     *Entry           `json:"stopTime,omitempty"`
+	StopTimes                    List             `json:"stopTimes,omitempty"`
+	StopTripGroupings            List             `json:"stopTripGroupings,omitempty"`
 	Summary                      []string         `json:"summary,omitempty"`
 	TextColor                    string           `json:"textColor,omitempty"`
 	Time                         int              `json:"time,omitempty"`
@@ -116,14 +130,39 @@ type Entry struct {
 	TotalDistanceAlongTrip       float64          `json:"totalDistanceAlongTrip"`
 	TotalStopsInTrip             int              `json:"totalStopsInTrip,omitempty"`
 	TripHeadSign                 string           `json:"tripHeadsign,omitempty"`
+	TripHeadsigns                []string         `json:"tripHeadsigns,omitempty"`
 	TripID                       string           `json:"tripId,omitempty"`
+	TripIDs                      []string         `json:"tripIds,omitempty"`
 	TripShortName                string           `json:"tripShortName,omitempty"`
 	TripStatus                   *Entry           `json:"tripStatus,omitempty"`
+	TripsWithStopTimes           List             `json:"tripsWithStopTimes,omitempty"`
 	Type                         int              `json:"type,omitempty"`
 	URL                          string           `json:"url,omitempty"`
 	VehicleID                    string           `json:"vehicleId,omitempty"`
 	WheelChairBoarding           string           `json:"wheelchairBoarding,omitempty"`
 	// Description       []string         `json:"description>value"` what the fuck
+
+	// trip details carry these as elements, see UnmarshalJSON
+	statusEntry   *Entry
+	scheduleEntry *Entry
+}
+
+// UnmarshalJSON - decode e, keeping the status and schedule elements of trip
+// details apart from the text status of other elements
+func (e *Entry) UnmarshalJSON(b []byte) error {
+	type entry Entry
+	aux := struct {
+		*entry
+		Status   textOrEntry `json:"status,omitempty"`
+		Schedule textOrEntry `json:"schedule,omitempty"`
+	}{entry: (*entry)(e)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+	e.Status = aux.Status.text
+	e.statusEntry = aux.Status.entry
+	e.scheduleEntry = aux.Schedule.entry
+	return nil
 }
 
 type AltEntry struct {
@@ -147,6 +186,26 @@ type NameEntry struct {
 	Type  string   `json:"type,omitempty"`
 }
 
+// textOrEntry - a field that is text on most elements but a nested element
+// on trip details, where status is a trip status and schedule a trip schedule
+type textOrEntry struct {
+	text  string
+	entry *Entry
+}
+
+func (t *textOrEntry) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	switch {
+	case bytes.Equal(b, []byte("null")):
+		return nil
+	case len(b) > 0 && b[0] == '{':
+		t.entry = &Entry{}
+		return json.Unmarshal(b, t.entry)
+	default:
+		return json.Unmarshal(b, &t.text)
+	}
+}
+
 func (e NameEntry) ToName() *Name {
 	return &Name{
 		Names: e.Names,
@@ -208,7 +267,7 @@ func (e Entry) ToArrivalAndDeparture(sis []Situation, st []Stop, ts []Trip) *Arr
 		BlockTripSequence:            e.BlockTripSequence,
 		DepartureEnabled:             e.DepartureEnabled,
 		DistanceFromStop:             e.DistanceFromStop,
-		Frequency:                    e.Frequency,
+		Frequency:                    e.frequency(),
 		LastUpdateTime:               e.LastUpdateTime,
 		NumberOfStopsAway:            e.NumberOfStopsAway,
 		Predicted:                    e.Predicted,
@@ -298,13 +357,27 @@ func (e AltEntry) ToEncodedPolyLine() *EncodedPolyLine {
 }
 
 func (e Entry) ToFrequency() *Frequency {
+	headway := e.HeadwaySecs
+	if headway == 0 {
+		headway = e.Headway
+	}
 	return &Frequency{
-		StartTime: e.StartTime,
-		EndTime:   e.EndTime,
-		Headway:   e.Headway,
+		StartTime:  e.StartTime,
+		EndTime:    e.EndTime,
+		Headway:    headway,
+		ExactTimes: e.ExactTimes != 0,
 	}
 }
 
+// frequency - the typed frequency of the entry, nil when the service is not
+// frequency based
+func (e Entry) frequency() *Frequency {
+	if e.Frequency == nil {
+		return nil
+	}
+	return e.Frequency.ToFrequency()
+}
+
 func (e Entry) ToLocation() *Location {
 	return &Location{
 		Lat: e.Lat,
@@ -339,6 +412,22 @@ func (e Entry) ToRoute(agencies []Agency) *Route {
 	}
 }
 
+func (e Entry) ToRouteSchedule(rs []Route, ss []Stop, ts []Trip) *RouteSchedule {
+	var route Route
+	for _, r := range rs {
+		if e.RouteID == r.ID {
+			route = r
+			break
+		}
+	}
+	return &RouteSchedule{
+		Route:             route,
+		ScheduleDate:      e.ScheduleDate,
+		ServiceIDs:        e.ServiceIDs,
+		StopTripGroupings: e.StopTripGroupings.toStopTripGroupings(ss, ts),
+	}
+}
+
 func (e Entry) ToScheduleStopTime() *ScheduleStopTime {
 	return &ScheduleStopTime{
 		ArrivalEnabled:   e.ArrivalEnabled,
@@ -347,6 +436,7 @@ func (e Entry) ToScheduleStopTime() *ScheduleStopTime {
 		DepartureTime:    e.DepartureTime,
 		ServiceID:        e.ServiceID,
 		StopHeadsign:     e.StopHeadsign,
+		StopID:           e.StopID,
 		TripID:           e.TripID,
 	}
 }
@@ -407,7 +497,15 @@ func (e Entry) ToStopRouteDirectionSchedule() *StopRouteDirectionSchedule {
 }
 
 func (e Entry) ToScheduleFrequency() *ScheduleFrequency {
-	return &ScheduleFrequency{}
+	return &ScheduleFrequency{
+		Frequency:        *e.ToFrequency(),
+		ArrivalEnabled:   e.ArrivalEnabled,
+		DepartureEnabled: e.DepartureEnabled,
+		ServiceDate:      e.ServiceDate,
+		ServiceID:        e.ServiceID,
+		StopHeadsign:     e.StopHeadsign,
+		TripID:           e.TripID,
+	}
 }
 
 func (e Entry) ToStopsForRoute(rs []Route, ss []Stop) *StopsForRoute {
@@ -441,7 +539,14 @@ func (e AltEntry) ToStopGrouping(ss []Stop) *StopGrouping {
 	}
 }
 
-func (e Entry) ToStopSchedule(ss []Stop) *StopSchedule {
+func (e Entry) ToStopCalendarDay() *StopCalendarDay {
+	return &StopCalendarDay{
+		Date:  e.Date,
+		Group: e.Group,
+	}
+}
+
+func (e Entry) ToStopSchedule(rs []Route, ss []Stop) *StopSchedule {
 	var stop Stop
 	for _, s := range ss {
 		if s.ID == e.StopID {
@@ -450,9 +555,11 @@ func (e Entry) ToStopSchedule(ss []Stop) *StopSchedule {
 		}
 	}
 	return &StopSchedule{
-		TimeZone: e.TimeZone,
-		Date:     e.Date,
-		Stop:     stop,
+		Date:               e.Date,
+		Stop:               stop,
+		StopCalendarDays:   e.StopCalendarDays.toStopCalendarDays(),
+		StopRouteSchedules: e.StopRouteSchedules.toStopRouteSchedules(rs),
+		TimeZone:           e.TimeZone,
 	}
 }
 
@@ -466,6 +573,34 @@ func (e Entry) ToStopTime() *StopTime {
 	}
 }
 
+func (e Entry) ToStopTripGrouping(ss []Stop, ts []Trip) *StopTripGrouping {
+	stops := make([]Stop, 0, len(e.StopIDs))
+	for _, sid := range e.StopIDs {
+		for _, s := range ss {
+			if sid == s.ID {
+				stops = append(stops, s)
+				break
+			}
+		}
+	}
+	trips := make([]Trip, 0, len(e.TripIDs))
+	for _, tid := range e.TripIDs {
+		for _, t := range ts {
+			if tid == t.ID {
+				trips = append(trips, t)
+				break
+			}
+		}
+	}
+	return &StopTripGrouping{
+		DirectionID:        e.DirectionID,
+		Stops:              stops,
+		TripHeadsigns:      e.TripHeadsigns,
+		Trips:              trips,
+		TripsWithStopTimes: e.TripsWithStopTimes.toTripsWithStopTimes(ts),
+	}
+}
+
 func (e Entry) ToTrip() *Trip {
 	return &Trip{
 		BlockID:        e.BlockID,
@@ -482,6 +617,12 @@ func (e Entry) ToTrip() *Trip {
 }
 
 func (e Entry) ToTripDetails(ts []Trip, ss []Situation) *TripDetails {
+	return e.tripDetails(ts, ss, nil)
+}
+
+// tripDetails - the trip details with the stops of its status and schedule
+// resolved from stops
+func (e Entry) tripDetails(ts []Trip, ss []Situation, stops []Stop) *TripDetails {
 	var trip Trip
 	for _, t := range ts {
 		if t.ID == e.TripID {
@@ -489,14 +630,71 @@ func (e Entry) ToTripDetails(ts []Trip, ss []Situation) *TripDetails {
 		}
 	}
 	return &TripDetails{
-		Frequency:   e.Frequency,
+		Frequency:   e.frequency(),
+		Schedule:    e.tripSchedule(stops),
 		ServiceDate: e.ServiceDate,
 		Situations:  ss,
 		Status:      e.Status,
+		TripStatus:  e.tripStatus(stops),
 		Trip:        trip,
 	}
 }
 
+func (e Entry) tripStatus(stops []Stop) *TripStatus {
+	if e.statusEntry == nil {
+		return nil
+	}
+	return e.statusEntry.ToTripStatus(stops)
+}
+
+func (e Entry) tripSchedule(stops []Stop) *TripSchedule {
+	if e.scheduleEntry == nil {
+		return nil
+	}
+	return e.scheduleEntry.ToTripSchedule(stops)
+}
+
+func (e Entry) ToTripSchedule(stops []Stop) *TripSchedule {
+	return &TripSchedule{
+		Frequency:      e.frequency(),
+		NextTripID:     e.NextTripID,
+		PreviousTripID: e.PreviousTripID,
+		StopTimes:      e.StopTimes.toTripStopTimes(stops),
+		TimeZone:       e.TimeZone,
+	}
+}
+
+func (e Entry) ToTripStopTime(stops []Stop) *TripStopTime {
+	stop := Stop{ID: e.StopID}
+	for _, s := range stops {
+		if s.ID == e.StopID {
+			stop = s
+			break
+		}
+	}
+	return &TripStopTime{
+		ArrivalTime:       e.ArrivalTime,
+		DepartureTime:     e.DepartureTime,
+		DistanceAlongTrip: e.DistanceAlongTrip,
+		Stop:              stop,
+		StopHeadsign:      e.StopHeadsign,
+	}
+}
+
+func (e Entry) ToTripWithStopTimes(ts []Trip) *TripWithStopTimes {
+	var trip Trip
+	for _, t := range ts {
+		if t.ID == e.TripID {
+			trip = t
+			break
+		}
+	}
+	return &TripWithStopTimes{
+		Trip:      trip,
+		StopTimes: e.StopTimes.toScheduleStopTimes(),
+	}
+}
+
 func (e Entry) ToTripStatus(ss []Stop) *TripStatus {
 	var cstop Stop
 	var nstop Stop
@@ -515,7 +713,7 @@ func (e Entry) ToTripStatus(ss []Stop) *TripStatus {
 		ClosestStop:                cstop,
 		ClosestStopTimeOffset:      e.ClosestStopTimeOffset,
 		DistanceAlongTrip:          e.DistanceAlongTrip,
-		Frequency:                  e.Frequency,
+		Frequency:                  e.frequency(),
 		LastKnownDistanceAlongTrip: e.LastKnownDistanceAlongTrip,
 		LastKnownLocation:          e.LastKnownLocation,
 		LastKnownOrientation:       e.LastKnownOrientation,
@@ -0,0 +1,67 @@
+// Package oba - One Bus Away Go Api https://onebusaway.org/
+// Author: Seth T <setheck@gmail.com>
+package oba
+
+import "time"
+
+// Start - the beginning of the frequency window
+func (f Frequency) Start() time.Time {
+	return MillisToTime(f.StartTime)
+}
+
+// End - the end of the frequency window
+func (f Frequency) End() time.Time {
+	return MillisToTime(f.EndTime)
+}
+
+// HeadwayDuration - the time between departures
+func (f Frequency) HeadwayDuration() time.Duration {
+	return time.Duration(f.Headway) * time.Second
+}
+
+// Contains - whether t falls within the frequency window
+func (f Frequency) Contains(t time.Time) bool {
+	return !t.Before(f.Start()) && !t.After(f.End())
+}
+
+// Departures - the departures of the window between from and to, inclusive.
+// Departures are spaced one headway apart starting at the window start; when
+// ExactTimes is false they are estimates rather than scheduled trips.
+func (f Frequency) Departures(from, to time.Time) []time.Time {
+	headway := f.HeadwayDuration()
+	if headway <= 0 {
+		return nil
+	}
+	first, ok := f.NextDeparture(from)
+	if !ok {
+		return nil
+	}
+	end := f.End()
+	if to.Before(end) {
+		end = to
+	}
+	var deps []time.Time
+	for d := first; !d.After(end); d = d.Add(headway) {
+		deps = append(deps, d)
+	}
+	return deps
+}
+
+// NextDeparture - the first departure at or after t, false once the window has
+// no departures left
+func (f Frequency) NextDeparture(t time.Time) (time.Time, bool) {
+	headway := f.HeadwayDuration()
+	if headway <= 0 {
+		return time.Time{}, false
+	}
+	start := f.Start()
+	next := start
+	if t.After(start) {
+		n := (t.Sub(start) + headway - 1) / headway
+		next = start.Add(n * headway)
+	}
+	if next.After(f.End()) {
+		return time.Time{}, false
+	}
+	return next, true
+}
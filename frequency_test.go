@@ -0,0 +1,67 @@
+package oba_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+var TestFrequencyStart = time.Date(2018, time.September, 20, 9, 0, 0, 0, time.UTC)
+
+func NewTestFrequency() oba.Frequency {
+	return oba.Frequency{
+		StartTime: int(TestFrequencyStart.UnixNano() / int64(time.Millisecond)),
+		EndTime:   int(TestFrequencyStart.Add(time.Hour).UnixNano() / int64(time.Millisecond)),
+		Headway:   600,
+	}
+}
+
+func TestFrequency_Window(t *testing.T) {
+	f := NewTestFrequency()
+	assert.True(t, f.Start().Equal(TestFrequencyStart), "Start")
+	assert.True(t, f.End().Equal(TestFrequencyStart.Add(time.Hour)), "End")
+	assert.Equal(t, 10*time.Minute, f.HeadwayDuration(), "HeadwayDuration")
+	assert.True(t, f.Contains(TestFrequencyStart.Add(30*time.Minute)), "Contains")
+	assert.False(t, f.Contains(TestFrequencyStart.Add(-time.Minute)), "Contains - before")
+	assert.False(t, f.Contains(TestFrequencyStart.Add(61*time.Minute)), "Contains - after")
+}
+
+func TestFrequency_NextDeparture(t *testing.T) {
+	f := NewTestFrequency()
+
+	next, ok := f.NextDeparture(TestFrequencyStart.Add(-time.Hour))
+	assert.True(t, ok)
+	assert.True(t, next.Equal(TestFrequencyStart), "before window")
+
+	next, ok = f.NextDeparture(TestFrequencyStart.Add(11 * time.Minute))
+	assert.True(t, ok)
+	assert.True(t, next.Equal(TestFrequencyStart.Add(20*time.Minute)), "inside window")
+
+	next, ok = f.NextDeparture(TestFrequencyStart.Add(20 * time.Minute))
+	assert.True(t, ok)
+	assert.True(t, next.Equal(TestFrequencyStart.Add(20*time.Minute)), "on departure")
+
+	_, ok = f.NextDeparture(TestFrequencyStart.Add(61 * time.Minute))
+	assert.False(t, ok, "after window")
+
+	f.Headway = 0
+	_, ok = f.NextDeparture(TestFrequencyStart)
+	assert.False(t, ok, "no headway")
+}
+
+func TestFrequency_Departures(t *testing.T) {
+	f := NewTestFrequency()
+
+	deps := f.Departures(TestFrequencyStart.Add(-time.Hour), TestFrequencyStart.Add(2*time.Hour))
+	assert.Len(t, deps, 7, "whole window")
+
+	deps = f.Departures(TestFrequencyStart.Add(15*time.Minute), TestFrequencyStart.Add(40*time.Minute))
+	if assert.Len(t, deps, 3, "partial window") {
+		assert.True(t, deps[0].Equal(TestFrequencyStart.Add(20*time.Minute)))
+		assert.True(t, deps[2].Equal(TestFrequencyStart.Add(40*time.Minute)))
+	}
+
+	assert.Empty(t, f.Departures(TestFrequencyStart.Add(2*time.Hour), TestFrequencyStart.Add(3*time.Hour)), "after window")
+}
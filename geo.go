@@ -0,0 +1,29 @@
+// Package oba - One Bus Away Go Api https://onebusaway.org/
+// Author: Seth T <setheck@gmail.com>
+package oba
+
+import "math"
+
+// earthRadius - mean radius of the earth in meters
+const earthRadius = 6371008.8
+
+// Haversine - great circle distance in meters between two coordinates given in
+// degrees
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(a))
+}
+
+// DistanceTo - great circle distance in meters to o
+func (l Location) DistanceTo(o Location) float64 {
+	return Haversine(l.Lat, l.Lon, o.Lat, o.Lon)
+}
+
+// Location - the coordinates of the stop
+func (s Stop) Location() Location {
+	return Location{Lat: s.Lat, Lon: s.Lon}
+}
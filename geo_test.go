@@ -0,0 +1,24 @@
+package oba_test
+
+import (
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHaversine(t *testing.T) {
+	assert.Zero(t, oba.Haversine(47.6, -122.3, 47.6, -122.3), "same point")
+
+	// one degree of latitude is roughly 111.2 km
+	assert.InDelta(t, 111195, oba.Haversine(47, -122, 48, -122), 10, "one degree")
+
+	// Seattle to Portland
+	d := oba.Location{Lat: 47.6062, Lon: -122.3321}.DistanceTo(oba.Location{Lat: 45.5152, Lon: -122.6784})
+	assert.InDelta(t, 233500, d, 1000, "Seattle - Portland")
+}
+
+func TestStop_Location(t *testing.T) {
+	s := oba.Stop{Lat: 47.654365, Lon: -122.305214}
+	assert.Equal(t, oba.Location{Lat: 47.654365, Lon: -122.305214}, s.Location())
+}
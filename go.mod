@@ -7,6 +7,8 @@ require (
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/spf13/cobra v0.0.3
+	github.com/spf13/pflag v1.0.2
 	github.com/spf13/viper v1.2.1
 	github.com/stretchr/testify v1.2.2
+	gopkg.in/yaml.v2 v2.2.1
 )
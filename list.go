@@ -107,6 +107,14 @@ func (l List) toStops(r []Route) []Stop {
 	return stops
 }
 
+func (l List) toStopCalendarDays() []StopCalendarDay {
+	scds := make([]StopCalendarDay, 0, len(l))
+	for _, entry := range l {
+		scds = append(scds, *entry.ToStopCalendarDay())
+	}
+	return scds
+}
+
 func (l List) toStopRouteDirectionSchedules() []StopRouteDirectionSchedule {
 	srds := make([]StopRouteDirectionSchedule, 0, len(l))
 	for _, entry := range l {
@@ -145,6 +153,14 @@ func (l List) toScheduleStopTimes() []ScheduleStopTime {
 	return ssts
 }
 
+func (l List) toTripStopTimes(ss []Stop) []TripStopTime {
+	tsts := make([]TripStopTime, 0, len(l))
+	for _, tst := range l {
+		tsts = append(tsts, *tst.ToTripStopTime(ss))
+	}
+	return tsts
+}
+
 func (l AltList) toStopGroupings(ss []Stop) []StopGrouping {
 	sgs := make([]StopGrouping, 0, len(l))
 	for _, entry := range l {
@@ -161,6 +177,14 @@ func (l AltList) toStopGroups(ss []Stop) []StopGroup {
 	return sgs
 }
 
+func (l List) toStopTripGroupings(ss []Stop, ts []Trip) []StopTripGrouping {
+	stgs := make([]StopTripGrouping, 0, len(l))
+	for _, entry := range l {
+		stgs = append(stgs, *entry.ToStopTripGrouping(ss, ts))
+	}
+	return stgs
+}
+
 func (l List) toTrips() []Trip {
 	trips := make([]Trip, 0, len(l))
 	for _, entry := range l {
@@ -169,14 +193,22 @@ func (l List) toTrips() []Trip {
 	return trips
 }
 
-func (l List) toTripDetails(ts []Trip, ss []Situation) []TripDetails {
+func (l List) toTripDetails(ts []Trip, ss []Situation, stops []Stop) []TripDetails {
 	tds := make([]TripDetails, 0, len(l))
 	for _, entry := range l {
-		tds = append(tds, *entry.ToTripDetails(ts, ss))
+		tds = append(tds, *entry.tripDetails(ts, ss, stops))
 	}
 	return tds
 }
 
+func (l List) toTripsWithStopTimes(ts []Trip) []TripWithStopTimes {
+	twsts := make([]TripWithStopTimes, 0, len(l))
+	for _, entry := range l {
+		twsts = append(twsts, *entry.ToTripWithStopTimes(ts))
+	}
+	return twsts
+}
+
 func (l List) toVehicleStatuses(st []Stop, ts []Trip) []VehicleStatus {
 	vss := make([]VehicleStatus, 0, len(l))
 	for _, entry := range l {
@@ -0,0 +1,119 @@
+// Package oba - One Bus Away Go Api https://onebusaway.org/
+// Author: Seth T <setheck@gmail.com>
+package oba
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level - the severity of a log message
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return fmt.Sprint("level(", int(l), ")")
+	}
+	return levelNames[l]
+}
+
+// ParseLevel - the level named s, one of debug, info, warn or error
+func ParseLevel(s string) (Level, error) {
+	for i, n := range levelNames {
+		if strings.EqualFold(s, n) {
+			return Level(i), nil
+		}
+	}
+	return LevelDebug, fmt.Errorf("unknown log level: %q", s)
+}
+
+// Field - a key and value attached to a log message
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F - a field
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger - receives the log messages of a client. Messages about a request
+// carry the fields "endpoint" and "request_id", and "duration" once it has
+// completed. A logger must be safe for concurrent use.
+type Logger interface {
+	Log(level Level, msg string, fields ...Field)
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Log(Level, string, ...Field) {}
+
+// NopLogger - a logger discarding every message, the default of a client
+var NopLogger Logger = nopLogger{}
+
+// textLogger - writes messages at or above min as a line of key=value pairs
+type textLogger struct {
+	mu  sync.Mutex
+	w   io.Writer
+	min Level
+	now func() time.Time
+}
+
+// NewTextLogger - a logger writing messages at or above min to w, one per line
+//
+//	time=2018-09-20T08:00:00Z level=warn msg="request failed" endpoint=stop request_id=3 error="..."
+func NewTextLogger(w io.Writer, min Level) Logger {
+	return &textLogger{w: w, min: min, now: time.Now}
+}
+
+func (l *textLogger) Log(level Level, msg string, fields ...Field) {
+	if level < l.min {
+		return
+	}
+	var b strings.Builder
+	b.WriteString("time=" + l.now().UTC().Format(time.RFC3339))
+	b.WriteString(" level=" + level.String())
+	b.WriteString(" msg=" + logValue(msg))
+	for _, f := range fields {
+		b.WriteString(" " + f.Key + "=" + logValue(f.Value))
+	}
+	b.WriteString("\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = io.WriteString(l.w, b.String())
+}
+
+// logValue - v formatted, quoted when it contains spaces, quotes or is empty
+func logValue(v interface{}) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
+
+// SetLogger - send the client's log messages to l, nil silences the client
+func (c *DefaultClient) SetLogger(l Logger) {
+	c.logger = l
+}
+
+func (c DefaultClient) log() Logger {
+	if c.logger == nil {
+		return NopLogger
+	}
+	return c.logger
+}
@@ -0,0 +1,100 @@
+package oba_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryLogger - records every message
+type memoryLogger struct {
+	mu       sync.Mutex
+	messages []string
+	fields   []map[string]interface{}
+	levels   []oba.Level
+}
+
+func (m *memoryLogger) Log(level oba.Level, msg string, fields ...oba.Field) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	fs := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		fs[f.Key] = f.Value
+	}
+	m.levels = append(m.levels, level)
+	m.messages = append(m.messages, msg)
+	m.fields = append(m.fields, fs)
+}
+
+func TestParseLevel(t *testing.T) {
+	l, err := oba.ParseLevel("WARN")
+	assert.NoError(t, err)
+	assert.Equal(t, oba.LevelWarn, l)
+	assert.Equal(t, "warn", l.String())
+	_, err = oba.ParseLevel("loud")
+	assert.Error(t, err)
+}
+
+func TestNewTextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := oba.NewTextLogger(&buf, oba.LevelInfo)
+	l.Log(oba.LevelDebug, "hidden")
+	l.Log(oba.LevelWarn, "request failed", oba.F("endpoint", "stop"), oba.F("error", "code: 404 resource not found"))
+
+	out := strings.TrimSpace(buf.String())
+	assert.NotContains(t, out, "hidden", "below minimum level")
+	assert.Contains(t, out, ` level=warn msg="request failed" endpoint=stop error="code: 404 resource not found"`)
+	assert.True(t, strings.HasPrefix(out, "time="))
+}
+
+func TestDefaultClient_SetLogger(t *testing.T) {
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	server := BatchServer(t, []byte("not json"), counts, &mu)
+	defer server.Close()
+
+	log := &memoryLogger{}
+	client := oba.NewDefaultClientS(server.URL, "secret")
+	client.SetLogger(log)
+	_, err := client.Stop("1_75403")
+	assert.Error(t, err)
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if !assert.Len(t, log.messages, 2) {
+		return
+	}
+	assert.Equal(t, "error un-marshaling response", log.messages[0])
+	assert.Equal(t, oba.LevelError, log.levels[0])
+	assert.Equal(t, "stop", log.fields[0]["endpoint"])
+	assert.Equal(t, "request failed", log.messages[1])
+	assert.Equal(t, oba.LevelWarn, log.levels[1])
+	assert.NotEmpty(t, log.fields[1]["request_id"])
+	assert.Equal(t, log.fields[0]["request_id"], log.fields[1]["request_id"], "same request")
+	assert.Contains(t, log.fields[1], "duration")
+}
+
+func TestDefaultClient_SetLoggerDebug(t *testing.T) {
+	server := FakeServer(t, ReadFile(t, "stop.json"))
+	defer server.Close()
+
+	log := &memoryLogger{}
+	client := oba.NewDefaultClientS(server.URL, "secret")
+	client.SetLogger(log)
+	_, err := client.Stop("1_75403")
+	assert.NoError(t, err)
+	_, err = client.Stop("1_75403")
+	assert.NoError(t, err)
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if assert.Len(t, log.messages, 2) {
+		assert.Equal(t, oba.LevelDebug, log.levels[0])
+		assert.NotContains(t, log.fields[0]["url"], "secret")
+		assert.NotEqual(t, log.fields[0]["request_id"], log.fields[1]["request_id"])
+	}
+}
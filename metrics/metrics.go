@@ -0,0 +1,166 @@
+// Package metrics - request metrics for a One Bus Away client in the
+// Prometheus text exposition format, without a dependency on a Prometheus
+// client library
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Setheck/oba"
+)
+
+// DefaultBuckets - upper bounds in seconds of the request latency histogram
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type requestKey struct {
+	endpoint, status, code string
+}
+
+type endpointStats struct {
+	buckets    []uint64
+	count      uint64
+	latencySum float64
+	decodeSum  float64
+	bytes      uint64
+	errors     uint64
+}
+
+// Collector - aggregates oba.RequestInfo into counters and histograms. It is
+// safe for concurrent use.
+//
+//	m := metrics.NewCollector(nil)
+//	client.SetObserver(m.Observe)
+//	http.Handle("/metrics", m)
+type Collector struct {
+	mu        sync.Mutex
+	buckets   []float64
+	requests  map[requestKey]uint64
+	endpoints map[string]*endpointStats
+}
+
+// NewCollector - a collector using latency histogram buckets, DefaultBuckets
+// when nil
+func NewCollector(buckets []float64) *Collector {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &Collector{
+		buckets:   b,
+		requests:  make(map[requestKey]uint64),
+		endpoints: make(map[string]*endpointStats),
+	}
+}
+
+// Observe - record a request, usable as an oba.Observer
+func (m *Collector) Observe(info oba.RequestInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := requestKey{endpoint: info.Endpoint, status: strconv.Itoa(info.StatusCode), code: strconv.Itoa(info.Code)}
+	m.requests[k]++
+
+	s, ok := m.endpoints[info.Endpoint]
+	if !ok {
+		s = &endpointStats{buckets: make([]uint64, len(m.buckets))}
+		m.endpoints[info.Endpoint] = s
+	}
+	latency := info.Latency.Seconds()
+	for i, b := range m.buckets {
+		if latency <= b {
+			s.buckets[i]++
+		}
+	}
+	s.count++
+	s.latencySum += latency
+	s.decodeSum += info.DecodeTime.Seconds()
+	s.bytes += uint64(info.Bytes)
+	if info.Err != nil {
+		s.errors++
+	}
+}
+
+// WriteTo - write every metric in the text exposition format
+func (m *Collector) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	b.WriteString("# HELP oba_requests_total Requests by endpoint, http status and response code.\n")
+	b.WriteString("# TYPE oba_requests_total counter\n")
+	keys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, c := keys[i], keys[j]
+		if a.endpoint != c.endpoint {
+			return a.endpoint < c.endpoint
+		}
+		if a.status != c.status {
+			return a.status < c.status
+		}
+		return a.code < c.code
+	})
+	for _, k := range keys {
+		fmt.Fprintf(&b, "oba_requests_total{endpoint=%q,status=%q,code=%q} %d\n", k.endpoint, k.status, k.code, m.requests[k])
+	}
+
+	names := make([]string, 0, len(m.endpoints))
+	for n := range m.endpoints {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	b.WriteString("# HELP oba_request_errors_total Requests that returned an error.\n")
+	b.WriteString("# TYPE oba_request_errors_total counter\n")
+	for _, n := range names {
+		fmt.Fprintf(&b, "oba_request_errors_total{endpoint=%q} %d\n", n, m.endpoints[n].errors)
+	}
+
+	b.WriteString("# HELP oba_response_bytes_total Bytes of response bodies read.\n")
+	b.WriteString("# TYPE oba_response_bytes_total counter\n")
+	for _, n := range names {
+		fmt.Fprintf(&b, "oba_response_bytes_total{endpoint=%q} %d\n", n, m.endpoints[n].bytes)
+	}
+
+	b.WriteString("# HELP oba_request_duration_seconds Request latency including decoding.\n")
+	b.WriteString("# TYPE oba_request_duration_seconds histogram\n")
+	for _, n := range names {
+		s := m.endpoints[n]
+		for i, le := range m.buckets {
+			fmt.Fprintf(&b, "oba_request_duration_seconds_bucket{endpoint=%q,le=%q} %d\n", n, formatFloat(le), s.buckets[i])
+		}
+		fmt.Fprintf(&b, "oba_request_duration_seconds_bucket{endpoint=%q,le=\"+Inf\"} %d\n", n, s.count)
+		fmt.Fprintf(&b, "oba_request_duration_seconds_sum{endpoint=%q} %s\n", n, formatFloat(s.latencySum))
+		fmt.Fprintf(&b, "oba_request_duration_seconds_count{endpoint=%q} %d\n", n, s.count)
+	}
+
+	b.WriteString("# HELP oba_decode_duration_seconds Time spent un-marshaling responses.\n")
+	b.WriteString("# TYPE oba_decode_duration_seconds summary\n")
+	for _, n := range names {
+		s := m.endpoints[n]
+		fmt.Fprintf(&b, "oba_decode_duration_seconds_sum{endpoint=%q} %s\n", n, formatFloat(s.decodeSum))
+		fmt.Fprintf(&b, "oba_decode_duration_seconds_count{endpoint=%q} %d\n", n, s.count)
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP - serve the metrics for scraping
+func (m *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = m.WriteTo(w)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
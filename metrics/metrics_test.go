@@ -0,0 +1,69 @@
+package metrics_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/Setheck/oba/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollector(t *testing.T) {
+	m := metrics.NewCollector([]float64{1, 0.1})
+	m.Observe(oba.RequestInfo{Endpoint: "stop", StatusCode: 200, Code: 200, Bytes: 100, Latency: 50 * time.Millisecond, DecodeTime: time.Millisecond})
+	m.Observe(oba.RequestInfo{Endpoint: "stop", StatusCode: 200, Code: 404, Bytes: 20, Latency: 500 * time.Millisecond, Err: errors.New("not found")})
+	m.Observe(oba.RequestInfo{Endpoint: "agency", Err: errors.New("connection refused"), Latency: 2 * time.Second})
+
+	rec := httptest.NewRecorder()
+	m.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	body, _ := ioutil.ReadAll(rec.Body)
+	text := string(body)
+
+	for _, line := range []string{
+		`oba_requests_total{endpoint="agency",status="0",code="0"} 1`,
+		`oba_requests_total{endpoint="stop",status="200",code="200"} 1`,
+		`oba_requests_total{endpoint="stop",status="200",code="404"} 1`,
+		`oba_request_errors_total{endpoint="stop"} 1`,
+		`oba_response_bytes_total{endpoint="stop"} 120`,
+		`oba_request_duration_seconds_bucket{endpoint="stop",le="0.1"} 1`,
+		`oba_request_duration_seconds_bucket{endpoint="stop",le="1"} 2`,
+		`oba_request_duration_seconds_bucket{endpoint="agency",le="1"} 0`,
+		`oba_request_duration_seconds_bucket{endpoint="agency",le="+Inf"} 1`,
+		`oba_request_duration_seconds_sum{endpoint="stop"} 0.55`,
+		`oba_request_duration_seconds_count{endpoint="stop"} 2`,
+		`oba_decode_duration_seconds_sum{endpoint="stop"} 0.001`,
+		"# TYPE oba_request_duration_seconds histogram",
+	} {
+		assert.Contains(t, text, line+"\n")
+	}
+	assert.True(t, strings.Index(text, `endpoint="agency"`) < strings.Index(text, `endpoint="stop"`), "sorted")
+}
+
+func TestCollector_Observer(t *testing.T) {
+	b, err := ioutil.ReadFile("../testdata/agency.json")
+	if !assert.NoError(t, err) {
+		return
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	m := metrics.NewCollector(nil)
+	client := oba.NewDefaultClientS(server.URL, "key")
+	client.SetObserver(m.Observe)
+	_, err = client.Agency("1")
+	assert.NoError(t, err)
+
+	var sb strings.Builder
+	_, err = m.WriteTo(&sb)
+	assert.NoError(t, err)
+	assert.Contains(t, sb.String(), `oba_requests_total{endpoint="agency",status="200",code="200"} 1`)
+}
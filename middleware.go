@@ -0,0 +1,98 @@
+// Package oba - One Bus Away Go Api https://onebusaway.org/
+// Author: Seth T <setheck@gmail.com>
+package oba
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Request - an api request passing through the middleware chain
+type Request struct {
+	// ID - unique id of the request within the process, also in RequestInfo
+	// and log messages
+	ID string
+	// Endpoint - the api method, e.g. "stop" or "search/route"
+	Endpoint string
+	// URL - the full request url, including the key
+	URL *url.URL
+	// Header - headers sent with the request
+	Header http.Header
+	// alt - the endpoint returns a list of ids, decoded as an AltResponse
+	alt bool
+}
+
+// RedactedURL - the request url with the key removed, for logging
+func (r *Request) RedactedURL() string {
+	return redactKey(r.URL.String())
+}
+
+// Result - the outcome of a request. The innermost handler sets every field;
+// a middleware short circuiting the chain may set only Body, it is decoded
+// after the chain returns.
+type Result struct {
+	StatusCode int
+	Body       []byte
+	// Response - the decoded body, nil for endpoints returning lists of ids
+	Response *Response
+	// AltResponse - the decoded body of endpoints returning lists of ids
+	AltResponse *AltResponse
+	decodeTime  time.Duration
+}
+
+// Handler - performs a request
+type Handler func(req *Request) (*Result, error)
+
+// Middleware - wraps a handler, it may change the request, inspect or replace
+// the result, or return without calling next
+type Middleware func(next Handler) Handler
+
+// Use - add middleware to the client, the first added is the outermost. Copies
+// of the client made before Use are not affected.
+func (c *DefaultClient) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware[:len(c.middleware):len(c.middleware)], mw...)
+}
+
+// chain - the middleware around the http handler
+func (c DefaultClient) chain() Handler {
+	h := c.send
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h
+}
+
+// send - the innermost handler, waits for the rate limiter, makes the http
+// request and decodes the body
+func (c DefaultClient) send(req *Request) (*Result, error) {
+	c.limiter.wait()
+	body, status, err := makeGetRequest(req)
+	res := &Result{StatusCode: status, Body: body}
+	if err != nil {
+		return res, err
+	}
+	res.decode(req, c.log())
+	return res, nil
+}
+
+// decode - decode the body unless the response the request expects is already
+// set. A body that does not decode leaves a response with a zero code, failing
+// the code check.
+func (r *Result) decode(req *Request, log Logger) {
+	if req.alt && r.AltResponse != nil || !req.alt && r.Response != nil {
+		return
+	}
+	start := time.Now()
+	var err error
+	if req.alt {
+		r.AltResponse, err = unmarshalAltResponse(r.Body)
+	} else {
+		r.Response, err = unmarshalResponse(r.Body)
+	}
+	r.decodeTime = time.Since(start)
+	if err != nil {
+		log.Log(LevelError, "error un-marshaling response",
+			F("endpoint", req.Endpoint), F("request_id", req.ID), F("duration", r.decodeTime), F("error", err))
+	}
+}
@@ -0,0 +1,128 @@
+package oba_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+// HeaderServer - serves body, recording the headers of the last request
+func HeaderServer(t *testing.T, body []byte, header *http.Header, mu *sync.Mutex) *httptest.Server {
+	t.Helper()
+	handler := http.HandlerFunc(func(r http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		*header = req.Header
+		mu.Unlock()
+		if _, err := r.Write(body); err != nil {
+			t.Error(err)
+		}
+	})
+	return httptest.NewServer(handler)
+}
+
+func TestDefaultClient_Use(t *testing.T) {
+	var header http.Header
+	var mu sync.Mutex
+	server := HeaderServer(t, ReadFile(t, "stop.json"), &header, &mu)
+	defer server.Close()
+
+	var order []string
+	var codes []int
+	var endpoints []string
+	client := oba.NewDefaultClientS(server.URL, "secret")
+	client.Use(
+		func(next oba.Handler) oba.Handler {
+			return func(req *oba.Request) (*oba.Result, error) {
+				order = append(order, "outer")
+				endpoints = append(endpoints, req.Endpoint)
+				assert.NotContains(t, req.RedactedURL(), "secret")
+				req.Header.Set("X-Trace", "abc")
+				return next(req)
+			}
+		},
+		func(next oba.Handler) oba.Handler {
+			return func(req *oba.Request) (*oba.Result, error) {
+				order = append(order, "inner")
+				res, err := next(req)
+				if err == nil {
+					codes = append(codes, res.Response.Code)
+				}
+				return res, err
+			}
+		},
+	)
+
+	s, err := client.Stop("1_75403")
+	assert.NoError(t, err)
+	assert.Equal(t, "1_75403", s.ID)
+	assert.Equal(t, []string{"outer", "inner"}, order, "first added is outermost")
+	assert.Equal(t, []string{"stop"}, endpoints)
+	assert.Equal(t, []int{200}, codes, "parsed response")
+	mu.Lock()
+	assert.Equal(t, "abc", header.Get("X-Trace"))
+	mu.Unlock()
+}
+
+func TestDefaultClient_UseShortCircuit(t *testing.T) {
+	var header http.Header
+	var mu sync.Mutex
+	server := HeaderServer(t, ReadFile(t, "stop.json"), &header, &mu)
+	defer server.Close()
+
+	client, infos := ObservedClient(server.URL)
+	client.Use(func(next oba.Handler) oba.Handler {
+		return func(req *oba.Request) (*oba.Result, error) {
+			if req.Endpoint == "stop-ids-for-agency" {
+				return &oba.Result{StatusCode: http.StatusOK, Body: []byte(`{"code":200,"data":{"list":["1_1","1_2"]}}`)}, nil
+			}
+			if req.Endpoint == "agency" {
+				return &oba.Result{Body: []byte(TestNotFound)}, nil
+			}
+			return next(req)
+		}
+	})
+
+	ids, err := client.StopIDsForAgency("1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1_1", "1_2"}, ids, "body decoded after the chain")
+	_, err = client.Agency("1")
+	assert.Error(t, err, "short circuit error code")
+	mu.Lock()
+	assert.Nil(t, header, "server not called")
+	mu.Unlock()
+
+	if assert.Len(t, *infos, 2) {
+		assert.Equal(t, 404, (*infos)[1].Code, "observer sees short circuited results")
+	}
+}
+
+func TestDefaultClient_UseShortCircuitOtherResponse(t *testing.T) {
+	client := oba.NewDefaultClientS("http://localhost:0", TestApiKey)
+	client.Use(func(next oba.Handler) oba.Handler {
+		return func(req *oba.Request) (*oba.Result, error) {
+			switch req.Endpoint {
+			case "stop-ids-for-agency":
+				return &oba.Result{StatusCode: http.StatusOK, Body: []byte(`{"code":200,"data":{"list":["1_1"]}}`),
+					Response: &oba.Response{Code: http.StatusOK}}, nil
+			case "agency":
+				return &oba.Result{StatusCode: http.StatusOK, Body: ReadFile(t, "agency.json"),
+					AltResponse: &oba.AltResponse{Code: http.StatusOK}}, nil
+			}
+			return &oba.Result{StatusCode: http.StatusOK, AltResponse: &oba.AltResponse{Code: http.StatusOK}}, nil
+		}
+	})
+
+	ids, err := client.StopIDsForAgency("1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1_1"}, ids, "alt body decoded despite a Response")
+	a, err := client.Agency("1")
+	if assert.NoError(t, err) {
+		assert.NotEmpty(t, a.ID, "body decoded despite an AltResponse")
+	}
+	_, err = client.Stop("1_75403")
+	assert.Error(t, err, "no body to decode")
+}
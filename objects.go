@@ -2,6 +2,7 @@ package oba
 
 import (
 	"encoding/json"
+	"time"
 )
 
 type ArrivalsAndDepartures []ArrivalAndDeparture
@@ -11,7 +12,7 @@ type ArrivalAndDeparture struct {
 	BlockTripSequence            int
 	DepartureEnabled             *bool
 	DistanceFromStop             float64
-	Frequency                    *string
+	Frequency                    *Frequency
 	LastUpdateTime               int
 	NumberOfStopsAway            int
 	Predicted                    *bool
@@ -96,10 +97,14 @@ func (s StopTime) String() string {
 	return jsonStringer(s)
 }
 
+// Frequency - a window of frequency-based service, "every Headway seconds
+// between StartTime and EndTime". Times are milliseconds since the Unix epoch.
+// When ExactTimes is false the departures are only an estimate of the service.
 type Frequency struct {
-	StartTime int
-	EndTime   int
-	Headway   int
+	StartTime  int
+	EndTime    int
+	Headway    int
+	ExactTimes bool
 }
 
 func (f Frequency) String() string {
@@ -278,7 +283,7 @@ func (s StopSchedule) String() string {
 }
 
 type StopCalendarDay struct {
-	Date  string
+	Date  int
 	Group string
 }
 
@@ -306,13 +311,52 @@ func (s StopRouteDirectionSchedule) String() string {
 }
 
 type ScheduleFrequency struct {
-	*Frequency
+	Frequency
+	ArrivalEnabled   *bool
+	DepartureEnabled *bool
+	ServiceDate      int
+	ServiceID        string
+	StopHeadsign     string
+	TripID           string
 }
 
 func (s ScheduleFrequency) String() string {
 	return jsonStringer(s)
 }
 
+// RouteSchedule - the full day timetable for a route
+type RouteSchedule struct {
+	Route             Route
+	ScheduleDate      int
+	ServiceIDs        []string
+	StopTripGroupings []StopTripGrouping
+}
+
+func (r RouteSchedule) String() string {
+	return jsonStringer(r)
+}
+
+type StopTripGrouping struct {
+	DirectionID        string
+	Stops              []Stop
+	TripHeadsigns      []string
+	Trips              []Trip
+	TripsWithStopTimes []TripWithStopTimes
+}
+
+func (s StopTripGrouping) String() string {
+	return jsonStringer(s)
+}
+
+type TripWithStopTimes struct {
+	Trip      Trip
+	StopTimes []ScheduleStopTime
+}
+
+func (t TripWithStopTimes) String() string {
+	return jsonStringer(t)
+}
+
 type StopsForRoute struct {
 	Route         Route
 	Stops         []Stop
@@ -371,6 +415,7 @@ type ScheduleStopTime struct {
 	DepartureTime    int
 	ServiceID        string
 	StopHeadsign     string
+	StopID           string
 	TripID           string
 }
 
@@ -398,8 +443,10 @@ func (t Trip) String() string {
 type TripDetails struct {
 	Trip        Trip
 	ServiceDate int
-	Frequency   *string
+	Frequency   *Frequency
+	Schedule    *TripSchedule
 	Status      string
+	TripStatus  *TripStatus
 	Situations  []Situation
 }
 
@@ -407,13 +454,39 @@ func (t TripDetails) String() string {
 	return jsonStringer(t)
 }
 
+// TripSchedule - the scheduled stop times of a trip, times are seconds since
+// the service date
+type TripSchedule struct {
+	Frequency      *Frequency
+	NextTripID     string
+	PreviousTripID string
+	StopTimes      []TripStopTime
+	TimeZone       string
+}
+
+func (t TripSchedule) String() string {
+	return jsonStringer(t)
+}
+
+type TripStopTime struct {
+	ArrivalTime       int
+	DepartureTime     int
+	DistanceAlongTrip float64
+	Stop              Stop
+	StopHeadsign      string
+}
+
+func (t TripStopTime) String() string {
+	return jsonStringer(t)
+}
+
 type TripStatus struct {
 	ActiveTripID               string
 	BlockTripSequence          int
 	ClosestStop                Stop
 	ClosestStopTimeOffset      int
 	DistanceAlongTrip          float64
-	Frequency                  *string
+	Frequency                  *Frequency
 	LastKnownDistanceAlongTrip float64
 	LastKnownLocation          Location
 	LastKnownOrientation       int
@@ -451,6 +524,7 @@ type StopWithArrivalsAndDepartures struct {
 	StopID                string
 	ArrivalsAndDepartures ArrivalsAndDepartures
 	NearByStopIDs         []string
+	Situations            []Situation
 }
 
 func (s StopWithArrivalsAndDepartures) String() string {
@@ -464,3 +538,9 @@ func jsonStringer(i interface{}) string {
 	}
 	return string(s)
 }
+
+// MillisToTime - convert milliseconds since the Unix epoch, as used throughout
+// the api, to a time
+func MillisToTime(ms int) time.Time {
+	return time.Unix(0, int64(ms)*int64(time.Millisecond))
+}
@@ -0,0 +1,77 @@
+// Package oba - One Bus Away Go Api https://onebusaway.org/
+// Author: Seth T <setheck@gmail.com>
+package oba
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RequestInfo - what happened during one api request
+type RequestInfo struct {
+	// Endpoint - the api method, e.g. "stop" or "search/route"
+	Endpoint string
+	// RequestID - unique id of the request within the process
+	RequestID string
+	// URL - the request url with the key removed
+	URL string
+	// StatusCode - the http status, zero when no response was received
+	StatusCode int
+	// Code - the code in the response body, zero when it was not decoded
+	Code int
+	// Bytes - size of the response body
+	Bytes int
+	// DecodeTime - time spent un-marshaling the response
+	DecodeTime time.Duration
+	// Latency - time from sending the request to the decoded response
+	Latency time.Duration
+	// Err - the error returned to the caller, if any
+	Err error
+}
+
+// Observer - called once per request after it completes. Batch methods make
+// requests concurrently so an observer must be safe for concurrent use.
+type Observer func(RequestInfo)
+
+// SetObserver - report every request made by the client to o, nil stops
+// reporting
+func (c *DefaultClient) SetObserver(o Observer) {
+	c.observer = o
+}
+
+func (c DefaultClient) observe(info RequestInfo) {
+	if c.observer != nil {
+		c.observer(info)
+	}
+}
+
+// endpointName - the api method of a request url, the path below the base url
+// without the format suffix and the trailing id
+func (c DefaultClient) endpointName(u string) string {
+	p, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	name := strings.TrimPrefix(p.Path, strings.TrimSuffix(c.baseURL.Path, "/"))
+	name = strings.TrimSuffix(strings.TrimPrefix(name, "/"), jsonPostFix)
+	if strings.HasPrefix(name, "search/") {
+		return name
+	}
+	if i := strings.Index(name, "/"); i >= 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+// redactKey - u with the api key removed
+func redactKey(u string) string {
+	p, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	q := p.Query()
+	q.Del("key")
+	p.RawQuery = q.Encode()
+	return p.String()
+}
@@ -0,0 +1,62 @@
+package oba_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
+)
+
+// ObservedClient - a client for server recording every RequestInfo
+func ObservedClient(url string) (*oba.DefaultClient, *[]oba.RequestInfo) {
+	var mu sync.Mutex
+	var infos []oba.RequestInfo
+	client := oba.NewDefaultClientS(url+"/api/where", "secret")
+	client.SetObserver(func(info oba.RequestInfo) {
+		mu.Lock()
+		defer mu.Unlock()
+		infos = append(infos, info)
+	})
+	return client, &infos
+}
+
+func TestDefaultClient_SetObserver(t *testing.T) {
+	body := ReadFile(t, "stop.json")
+	counts := make(map[string]int)
+	var mu sync.Mutex
+	server := BatchServer(t, body, counts, &mu)
+	defer server.Close()
+
+	client, infos := ObservedClient(server.URL)
+	_, err := client.Stop("1_75403")
+	assert.NoError(t, err)
+	_, err = client.Stop("missing")
+	assert.Error(t, err)
+	_, _ = client.SearchRoute("44", nil)
+	_, _ = client.StopIDsForAgency("1")
+
+	if !assert.Len(t, *infos, 4) {
+		return
+	}
+	ok := (*infos)[0]
+	assert.Equal(t, "stop", ok.Endpoint)
+	assert.NotContains(t, ok.URL, "secret", "key removed")
+	assert.Contains(t, ok.URL, "/api/where/stop/1_75403.json")
+	assert.Equal(t, 200, ok.StatusCode)
+	assert.Equal(t, 200, ok.Code)
+	assert.Equal(t, len(body), ok.Bytes)
+	assert.True(t, ok.Latency >= ok.DecodeTime)
+	assert.NoError(t, ok.Err)
+
+	missing := (*infos)[1]
+	assert.Equal(t, 404, missing.Code)
+	assert.Error(t, missing.Err)
+
+	assert.Equal(t, "search/route", (*infos)[2].Endpoint)
+	assert.Equal(t, "stop-ids-for-agency", (*infos)[3].Endpoint)
+
+	client.SetObserver(nil)
+	_, _ = client.Stop("1_75403")
+	assert.Len(t, *infos, 4, "observer removed")
+}
@@ -0,0 +1,19 @@
+// Package oba - One Bus Away Go Api https://onebusaway.org/
+// Author: Seth T <setheck@gmail.com>
+package oba
+
+import "strconv"
+
+// LocationParams - request parameters for the *-for-location methods centered
+// on lat, lon. A non-empty query narrows the search to a route short name for
+// RoutesForLocation or a stop code for StopsForLocation.
+func LocationParams(lat, lon float64, query string) map[string]string {
+	params := map[string]string{
+		"lat": strconv.FormatFloat(lat, 'f', -1, 64),
+		"lon": strconv.FormatFloat(lon, 'f', -1, 64),
+	}
+	if query != "" {
+		params["query"] = query
+	}
+	return params
+}
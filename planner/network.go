@@ -0,0 +1,232 @@
+// Package planner - schedule based journey planning on One Bus Away data
+package planner
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Setheck/oba"
+)
+
+// Catalog - the schedule data a Network is built from. A catalog can be
+// fetched from a server with Fetch, or saved and loaded as json for offline use.
+type Catalog struct {
+	Stops          []oba.Stop
+	RouteSchedules []oba.RouteSchedule
+	StopSchedules  []oba.StopSchedule
+}
+
+// Fetch - build a catalog for routeIDs on date from StopsForRoute and
+// ScheduleForRoute. A zero date fetches the current service date.
+func Fetch(client oba.Client, routeIDs []string, date time.Time) (*Catalog, error) {
+	c := &Catalog{}
+	for _, id := range routeIDs {
+		sfr, err := client.StopsForRoute(id)
+		if err != nil {
+			return nil, err
+		}
+		if sfr != nil {
+			c.Stops = append(c.Stops, sfr.Stops...)
+		}
+		rs, err := client.ScheduleForRoute(id, date)
+		if err != nil {
+			return nil, err
+		}
+		if rs != nil {
+			c.RouteSchedules = append(c.RouteSchedules, *rs)
+		}
+	}
+	return c, nil
+}
+
+// Connection - a vehicle travelling between two consecutive stops of a trip
+type Connection struct {
+	TripID    string
+	RouteID   string
+	Headsign  string
+	FromStop  string
+	ToStop    string
+	Departure time.Time
+	Arrival   time.Time
+	// CanBoard - riders may board at FromStop
+	CanBoard bool
+	// CanAlight - riders may alight at ToStop
+	CanAlight bool
+}
+
+// Network - the connections and stops used for planning
+type Network struct {
+	stops       map[string]oba.Stop
+	connections []Connection
+}
+
+// NewNetwork - build a network from the scheduled stop times in c. Trips found
+// in stop schedules are joined across stops by trip id, so they only produce
+// connections between stops that are both in the catalog.
+func NewNetwork(c *Catalog) *Network {
+	n := &Network{stops: make(map[string]oba.Stop)}
+	for _, s := range c.Stops {
+		n.stops[s.ID] = s
+	}
+	for _, rs := range c.RouteSchedules {
+		for _, stg := range rs.StopTripGroupings {
+			for _, s := range stg.Stops {
+				if _, ok := n.stops[s.ID]; !ok {
+					n.stops[s.ID] = s
+				}
+			}
+			for _, tws := range stg.TripsWithStopTimes {
+				headsign := tws.Trip.TripHeadsign
+				if headsign == "" && len(stg.TripHeadsigns) > 0 {
+					headsign = stg.TripHeadsigns[0]
+				}
+				n.addTrip(tws.Trip.ID, rs.Route.ID, headsign, tws.StopTimes)
+			}
+		}
+	}
+
+	type stopTrip struct {
+		routeID string
+		times   []oba.ScheduleStopTime
+	}
+	trips := make(map[string]*stopTrip)
+	var order []string
+	for _, ss := range c.StopSchedules {
+		if _, ok := n.stops[ss.Stop.ID]; !ok {
+			n.stops[ss.Stop.ID] = ss.Stop
+		}
+		for _, srs := range ss.StopRouteSchedules {
+			for _, srds := range srs.StopRouteDirectionSchedules {
+				for _, sst := range srds.ScheduleStopTimes {
+					if sst.StopID == "" {
+						sst.StopID = ss.Stop.ID
+					}
+					if sst.StopHeadsign == "" {
+						sst.StopHeadsign = srds.TripHeadsign
+					}
+					t, ok := trips[sst.TripID]
+					if !ok {
+						t = &stopTrip{routeID: srs.Route.ID}
+						trips[sst.TripID] = t
+						order = append(order, sst.TripID)
+					}
+					t.times = append(t.times, sst)
+				}
+			}
+		}
+	}
+	for _, id := range order {
+		t := trips[id]
+		sort.SliceStable(t.times, func(i, j int) bool {
+			return t.times[i].ArrivalTime < t.times[j].ArrivalTime
+		})
+		n.addTrip(id, t.routeID, "", t.times)
+	}
+
+	n.sortConnections()
+	return n
+}
+
+// addTrip - add a connection for each consecutive pair of stop times
+func (n *Network) addTrip(tripID, routeID, headsign string, times []oba.ScheduleStopTime) {
+	for i := 0; i+1 < len(times); i++ {
+		from, to := times[i], times[i+1]
+		h := headsign
+		if h == "" {
+			h = from.StopHeadsign
+		}
+		n.connections = append(n.connections, Connection{
+			TripID:    tripID,
+			RouteID:   routeID,
+			Headsign:  h,
+			FromStop:  from.StopID,
+			ToStop:    to.StopID,
+			Departure: oba.MillisToTime(from.DepartureTime),
+			Arrival:   oba.MillisToTime(to.ArrivalTime),
+			CanBoard:  enabled(from.DepartureEnabled),
+			CanAlight: enabled(to.ArrivalEnabled),
+		})
+	}
+}
+
+func (n *Network) sortConnections() {
+	sort.SliceStable(n.connections, func(i, j int) bool {
+		return n.connections[i].Departure.Before(n.connections[j].Departure)
+	})
+}
+
+// Connections - the connections of the network ordered by departure
+func (n *Network) Connections() []Connection {
+	return n.connections
+}
+
+// Stop - a stop of the network by id
+func (n *Network) Stop(id string) (oba.Stop, bool) {
+	s, ok := n.stops[id]
+	return s, ok
+}
+
+// ApplyPredictions - overlay real-time predictions from
+// ArrivalsAndDeparturesForStop. Each predicted trip is shifted by its
+// deviation from schedule from the predicted stop onwards.
+func (n *Network) ApplyPredictions(swad *oba.StopWithArrivalsAndDepartures) {
+	if swad == nil {
+		return
+	}
+	changed := false
+	for _, aad := range swad.ArrivalsAndDepartures {
+		if aad.Predicted == nil || !*aad.Predicted {
+			continue
+		}
+		var delay time.Duration
+		switch {
+		case aad.PredictedDepartureTime > 0 && aad.ScheduledDepartureTime > 0:
+			delay = oba.MillisToTime(aad.PredictedDepartureTime).Sub(oba.MillisToTime(aad.ScheduledDepartureTime))
+		case aad.PredictedArrivalTime > 0 && aad.ScheduledArrivalTime > 0:
+			delay = oba.MillisToTime(aad.PredictedArrivalTime).Sub(oba.MillisToTime(aad.ScheduledArrivalTime))
+		default:
+			continue
+		}
+		stopID := aad.StopID
+		if stopID == "" {
+			stopID = swad.StopID
+		}
+		if n.delayTrip(aad.TripID, stopID, oba.MillisToTime(aad.ScheduledDepartureTime), delay) {
+			changed = true
+		}
+	}
+	if changed {
+		n.sortConnections()
+	}
+}
+
+// delayTrip - shift the connections of trip departing stopID at or after
+// scheduled by delay
+func (n *Network) delayTrip(tripID, stopID string, scheduled time.Time, delay time.Duration) bool {
+	var from time.Time
+	found := false
+	for _, c := range n.connections {
+		if c.TripID == tripID && c.FromStop == stopID && (scheduled.IsZero() || !c.Departure.Before(scheduled)) {
+			if !found || c.Departure.Before(from) {
+				from = c.Departure
+			}
+			found = true
+		}
+	}
+	if !found || delay == 0 {
+		return false
+	}
+	for i := range n.connections {
+		c := &n.connections[i]
+		if c.TripID == tripID && !c.Departure.Before(from) {
+			c.Departure = c.Departure.Add(delay)
+			c.Arrival = c.Arrival.Add(delay)
+		}
+	}
+	return true
+}
+
+// enabled - stop times without the flag allow boarding and alighting
+func enabled(b *bool) bool {
+	return b == nil || *b
+}
@@ -0,0 +1,354 @@
+package planner
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Setheck/oba"
+)
+
+const (
+	defaultMaxWalkDistance = 800
+	defaultWalkSpeed       = 1.3
+	defaultMinTransferTime = 2 * time.Minute
+	defaultMaxTransfers    = 3
+)
+
+// Place - an origin or destination, either a stop of the network or a location
+type Place struct {
+	StopID   string
+	Location *oba.Location
+}
+
+// StopPlace - a place at the stop with id
+func StopPlace(id string) Place {
+	return Place{StopID: id}
+}
+
+// LocationPlace - a place at lat, lon
+func LocationPlace(lat, lon float64) Place {
+	return Place{Location: &oba.Location{Lat: lat, Lon: lon}}
+}
+
+// Options - limits of a journey search, zero values use the defaults
+type Options struct {
+	// MaxWalkDistance - meters walked for access, egress and each transfer
+	// (default 800)
+	MaxWalkDistance float64
+	// WalkSpeed - meters per second (default 1.3)
+	WalkSpeed float64
+	// MinTransferTime - time needed to change vehicles (default 2m)
+	MinTransferTime time.Duration
+	// MaxTransfers - vehicle changes allowed (default 3)
+	MaxTransfers int
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxWalkDistance <= 0 {
+		o.MaxWalkDistance = defaultMaxWalkDistance
+	}
+	if o.WalkSpeed <= 0 {
+		o.WalkSpeed = defaultWalkSpeed
+	}
+	if o.MinTransferTime <= 0 {
+		o.MinTransferTime = defaultMinTransferTime
+	}
+	if o.MaxTransfers <= 0 {
+		o.MaxTransfers = defaultMaxTransfers
+	}
+	return o
+}
+
+func (o Options) walk(meters float64) time.Duration {
+	return time.Duration(meters / o.WalkSpeed * float64(time.Second))
+}
+
+// Mode - how a leg is travelled
+type Mode string
+
+const (
+	Walk    Mode = "WALK"
+	Transit Mode = "TRANSIT"
+)
+
+// Leg - one part of an itinerary. FromStop and ToStop are empty for the
+// origin and destination of a location place.
+type Leg struct {
+	Mode      Mode
+	FromStop  string
+	ToStop    string
+	Departure time.Time
+	Arrival   time.Time
+	// Distance - meters walked, zero for transit legs
+	Distance float64
+	TripID   string
+	RouteID  string
+	Headsign string
+}
+
+// Itinerary - a journey from origin to destination
+type Itinerary struct {
+	Legs      []Leg
+	Departure time.Time
+	Arrival   time.Time
+	Transfers int
+}
+
+// Duration - time from departure to arrival
+func (i Itinerary) Duration() time.Duration {
+	return i.Arrival.Sub(i.Departure)
+}
+
+var (
+	ErrUnknownStop = errors.New("planner: unknown stop")
+	ErrNoPlace     = errors.New("planner: place needs a stop id or location")
+)
+
+// access - a stop reachable on foot from a place
+type access struct {
+	stop     string
+	distance float64
+}
+
+// label - how a stop was reached in a round
+type label struct {
+	arrival time.Time
+	// transit legs
+	enter, exit int
+	// walking transfers
+	walkFrom string
+	distance float64
+	walked   bool
+}
+
+// Plan - search for itineraries leaving from at or after depart and arriving
+// at to. The result holds the earliest arriving itinerary for each number of
+// transfers, skipping those that do not arrive earlier than one with fewer
+// transfers, ordered by transfers. Walking the whole way is included when to is
+// within MaxWalkDistance of from.
+func (n *Network) Plan(from, to Place, depart time.Time, opts Options) ([]Itinerary, error) {
+	opts = opts.withDefaults()
+	origins, err := n.nearby(from, opts)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := n.nearby(to, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var itineraries []Itinerary
+	best := time.Time{}
+	if d, ok := n.direct(from, to); ok && d <= opts.MaxWalkDistance {
+		arrival := depart.Add(opts.walk(d))
+		itineraries = append(itineraries, Itinerary{
+			Legs:      []Leg{n.walkLeg(from.StopID, to.StopID, depart, arrival, d)},
+			Departure: depart,
+			Arrival:   arrival,
+		})
+		best = arrival
+	}
+
+	// round 0 holds the access walks, round k the stops reached by k rides
+	rounds := opts.MaxTransfers + 1
+	labels := make([]map[string]label, rounds+1)
+	rides := make([]map[string]label, rounds+1)
+	labels[0] = make(map[string]label)
+	for _, a := range origins {
+		labels[0][a.stop] = label{arrival: depart.Add(opts.walk(a.distance)), distance: a.distance, walked: true}
+	}
+
+	for k := 1; k <= rounds; k++ {
+		prev := labels[k-1]
+		ride := make(map[string]label)
+		boarded := make(map[string]int)
+		for i, c := range n.connections {
+			if c.Departure.Before(depart) {
+				continue
+			}
+			if _, ok := boarded[c.TripID]; !ok && c.CanBoard {
+				if l, ok := prev[c.FromStop]; ok {
+					ready := l.arrival
+					if k > 1 {
+						ready = ready.Add(opts.MinTransferTime)
+					}
+					if !c.Departure.Before(ready) {
+						boarded[c.TripID] = i
+					}
+				}
+			}
+			enter, ok := boarded[c.TripID]
+			if !ok || !c.CanAlight {
+				continue
+			}
+			if l, ok := ride[c.ToStop]; !ok || c.Arrival.Before(l.arrival) {
+				ride[c.ToStop] = label{arrival: c.Arrival, enter: enter, exit: i}
+			}
+		}
+		if len(ride) == 0 {
+			break
+		}
+		rides[k] = ride
+
+		labels[k] = make(map[string]label, len(ride))
+		for s, l := range ride {
+			labels[k][s] = l
+		}
+		for s, l := range ride {
+			for _, a := range n.walkable(s, opts) {
+				arrival := l.arrival.Add(opts.walk(a.distance))
+				if cur, ok := labels[k][a.stop]; !ok || arrival.Before(cur.arrival) {
+					labels[k][a.stop] = label{arrival: arrival, walkFrom: s, distance: a.distance, walked: true}
+				}
+			}
+		}
+
+		var found *Itinerary
+		for _, t := range targets {
+			l, ok := labels[k][t.stop]
+			if !ok || (l.walked && t.distance > 0) {
+				continue
+			}
+			arrival := l.arrival.Add(opts.walk(t.distance))
+			if found == nil || arrival.Before(found.Arrival) {
+				it := n.itinerary(labels, rides, k, t, from, to, depart, arrival)
+				found = &it
+			}
+		}
+		if found != nil && (best.IsZero() || found.Arrival.Before(best)) {
+			itineraries = append(itineraries, *found)
+			best = found.Arrival
+		}
+	}
+	return itineraries, nil
+}
+
+// itinerary - walk the labels back from target t reached in round k
+func (n *Network) itinerary(labels, rides []map[string]label, k int, t access, from, to Place, depart, arrival time.Time) Itinerary {
+	var legs []Leg
+	if t.distance > 0 || to.StopID != t.stop {
+		legs = append(legs, n.walkLeg(t.stop, to.StopID, labels[k][t.stop].arrival, arrival, t.distance))
+	}
+
+	stop := t.stop
+	for r := k; r > 0; r-- {
+		l := labels[r][stop]
+		if l.walked {
+			legs = append(legs, n.walkLeg(l.walkFrom, stop, rides[r][l.walkFrom].arrival, l.arrival, l.distance))
+			stop = l.walkFrom
+			l = rides[r][stop]
+		}
+		enter, exit := n.connections[l.enter], n.connections[l.exit]
+		legs = append(legs, Leg{
+			Mode:      Transit,
+			FromStop:  enter.FromStop,
+			ToStop:    exit.ToStop,
+			Departure: enter.Departure,
+			Arrival:   exit.Arrival,
+			TripID:    enter.TripID,
+			RouteID:   enter.RouteID,
+			Headsign:  enter.Headsign,
+		})
+		stop = enter.FromStop
+	}
+
+	if a := labels[0][stop]; a.distance > 0 || from.StopID != stop {
+		legs = append(legs, n.walkLeg(from.StopID, stop, depart, a.arrival, a.distance))
+	}
+
+	for i, j := 0, len(legs)-1; i < j; i, j = i+1, j-1 {
+		legs[i], legs[j] = legs[j], legs[i]
+	}
+	// leave as late as possible for the first ride
+	if len(legs) > 1 && legs[0].Mode == Walk {
+		d := legs[0].Arrival.Sub(legs[0].Departure)
+		legs[0].Arrival = legs[1].Departure
+		legs[0].Departure = legs[1].Departure.Add(-d)
+	}
+	return Itinerary{
+		Legs:      legs,
+		Departure: legs[0].Departure,
+		Arrival:   arrival,
+		Transfers: k - 1,
+	}
+}
+
+func (n *Network) walkLeg(from, to string, departure, arrival time.Time, distance float64) Leg {
+	return Leg{
+		Mode:      Walk,
+		FromStop:  from,
+		ToStop:    to,
+		Departure: departure,
+		Arrival:   arrival,
+		Distance:  distance,
+	}
+}
+
+// location - the coordinates of a place
+func (n *Network) location(p Place) (oba.Location, error) {
+	if p.StopID != "" {
+		s, ok := n.stops[p.StopID]
+		if !ok {
+			return oba.Location{}, ErrUnknownStop
+		}
+		return s.Location(), nil
+	}
+	if p.Location == nil {
+		return oba.Location{}, ErrNoPlace
+	}
+	return *p.Location, nil
+}
+
+// nearby - the stops within walking distance of a place, a stop place always
+// includes its own stop
+func (n *Network) nearby(p Place, opts Options) ([]access, error) {
+	loc, err := n.location(p)
+	if err != nil {
+		return nil, err
+	}
+	var as []access
+	for id, s := range n.stops {
+		d := loc.DistanceTo(s.Location())
+		if id == p.StopID {
+			d = 0
+		}
+		if d <= opts.MaxWalkDistance {
+			as = append(as, access{stop: id, distance: d})
+		}
+	}
+	return as, nil
+}
+
+// walkable - the other stops within walking distance of stop
+func (n *Network) walkable(stop string, opts Options) []access {
+	s, ok := n.stops[stop]
+	if !ok {
+		return nil
+	}
+	var as []access
+	for id, o := range n.stops {
+		if id == stop {
+			continue
+		}
+		if d := s.Location().DistanceTo(o.Location()); d <= opts.MaxWalkDistance {
+			as = append(as, access{stop: id, distance: d})
+		}
+	}
+	return as
+}
+
+// direct - walking distance between two places
+func (n *Network) direct(from, to Place) (float64, bool) {
+	if from.StopID != "" && from.StopID == to.StopID {
+		return 0, true
+	}
+	a, err := n.location(from)
+	if err != nil {
+		return 0, false
+	}
+	b, err := n.location(to)
+	if err != nil {
+		return 0, false
+	}
+	return a.DistanceTo(b), true
+}
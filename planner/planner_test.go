@@ -0,0 +1,195 @@
+package planner_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Setheck/oba"
+	"github.com/Setheck/oba/planner"
+	"github.com/stretchr/testify/assert"
+)
+
+var TestDay = time.Date(2018, time.September, 20, 0, 0, 0, 0, time.UTC)
+
+func at(h, m int) time.Time {
+	return TestDay.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute)
+}
+
+func ms(t time.Time) int {
+	return int(t.UnixNano() / int64(time.Millisecond))
+}
+
+func stopTime(stop, trip string, t time.Time) oba.ScheduleStopTime {
+	return oba.ScheduleStopTime{StopID: stop, TripID: trip, ArrivalTime: ms(t), DepartureTime: ms(t)}
+}
+
+func trip(id string, times ...oba.ScheduleStopTime) oba.TripWithStopTimes {
+	return oba.TripWithStopTimes{Trip: oba.Trip{ID: id}, StopTimes: times}
+}
+
+// NewTestCatalog - route R1 runs A - B - C, route R2 runs E - D where E is a
+// short walk from C
+func NewTestCatalog() *planner.Catalog {
+	return &planner.Catalog{
+		Stops: []oba.Stop{
+			{ID: "A", Lat: 47.60, Lon: -122.30},
+			{ID: "B", Lat: 47.61, Lon: -122.30},
+			{ID: "C", Lat: 47.62, Lon: -122.30},
+			{ID: "E", Lat: 47.621, Lon: -122.30},
+			{ID: "D", Lat: 47.63, Lon: -122.30},
+		},
+		RouteSchedules: []oba.RouteSchedule{
+			{
+				Route: oba.Route{ID: "R1"},
+				StopTripGroupings: []oba.StopTripGrouping{{
+					TripHeadsigns: []string{"North"},
+					TripsWithStopTimes: []oba.TripWithStopTimes{
+						trip("t1", stopTime("A", "t1", at(8, 0)), stopTime("B", "t1", at(8, 5)), stopTime("C", "t1", at(8, 10))),
+					},
+				}},
+			},
+			{
+				Route: oba.Route{ID: "R2"},
+				StopTripGroupings: []oba.StopTripGrouping{{
+					TripHeadsigns: []string{"East"},
+					TripsWithStopTimes: []oba.TripWithStopTimes{
+						trip("t2", stopTime("E", "t2", at(8, 11)), stopTime("D", "t2", at(8, 16))),
+						trip("t3", stopTime("E", "t3", at(8, 15)), stopTime("D", "t3", at(8, 20))),
+						trip("t4", stopTime("E", "t4", at(8, 30)), stopTime("D", "t4", at(8, 35))),
+					},
+				}},
+			},
+		},
+	}
+}
+
+func TestNewNetwork(t *testing.T) {
+	n := planner.NewNetwork(NewTestCatalog())
+	cs := n.Connections()
+	assert.Len(t, cs, 5, "Connections")
+	for i := 1; i < len(cs); i++ {
+		assert.False(t, cs[i].Departure.Before(cs[i-1].Departure), "ordered")
+	}
+	_, ok := n.Stop("A")
+	assert.True(t, ok, "Stop")
+}
+
+func TestNetwork_PlanTransfer(t *testing.T) {
+	n := planner.NewNetwork(NewTestCatalog())
+
+	its, err := n.Plan(planner.StopPlace("A"), planner.StopPlace("D"), at(7, 55), planner.Options{})
+	assert.NoError(t, err)
+	if !assert.Len(t, its, 1, "Itineraries") {
+		return
+	}
+	it := its[0]
+	assert.Equal(t, 1, it.Transfers, "Transfers")
+	assert.True(t, it.Arrival.Equal(at(8, 20)), "Arrival")
+	assert.True(t, it.Departure.Equal(at(8, 0)), "Departure")
+	if assert.Len(t, it.Legs, 3, "Legs") {
+		assert.Equal(t, planner.Transit, it.Legs[0].Mode)
+		assert.Equal(t, "t1", it.Legs[0].TripID)
+		assert.Equal(t, "R1", it.Legs[0].RouteID)
+		assert.Equal(t, "North", it.Legs[0].Headsign)
+		assert.Equal(t, planner.Walk, it.Legs[1].Mode)
+		assert.Equal(t, "C", it.Legs[1].FromStop)
+		assert.Equal(t, "E", it.Legs[1].ToStop)
+		assert.InDelta(t, 111, it.Legs[1].Distance, 1)
+		assert.Equal(t, "t3", it.Legs[2].TripID, "misses t2 while transferring")
+	}
+}
+
+func TestNetwork_PlanLocation(t *testing.T) {
+	n := planner.NewNetwork(NewTestCatalog())
+
+	its, err := n.Plan(planner.LocationPlace(47.599, -122.30), planner.LocationPlace(47.6201, -122.30), at(7, 55), planner.Options{})
+	assert.NoError(t, err)
+	if !assert.Len(t, its, 1, "Itineraries") {
+		return
+	}
+	it := its[0]
+	assert.Equal(t, 0, it.Transfers, "Transfers")
+	if assert.Len(t, it.Legs, 3, "Legs") {
+		assert.Equal(t, planner.Walk, it.Legs[0].Mode)
+		assert.Equal(t, "", it.Legs[0].FromStop)
+		assert.True(t, it.Legs[0].Arrival.Equal(at(8, 0)), "walk ends at boarding")
+		assert.Equal(t, planner.Transit, it.Legs[1].Mode)
+		assert.Equal(t, planner.Walk, it.Legs[2].Mode)
+		assert.Equal(t, "", it.Legs[2].ToStop)
+	}
+}
+
+func TestNetwork_PlanWalk(t *testing.T) {
+	n := planner.NewNetwork(NewTestCatalog())
+
+	its, err := n.Plan(planner.StopPlace("A"), planner.StopPlace("B"), at(7, 55), planner.Options{MaxWalkDistance: 2000})
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, its, "Itineraries") {
+		assert.Len(t, its[0].Legs, 1, "walk only")
+		assert.Equal(t, planner.Walk, its[0].Legs[0].Mode)
+	}
+
+	_, err = n.Plan(planner.StopPlace("missing"), planner.StopPlace("B"), at(7, 55), planner.Options{})
+	assert.Equal(t, planner.ErrUnknownStop, err)
+	_, err = n.Plan(planner.Place{}, planner.StopPlace("B"), at(7, 55), planner.Options{})
+	assert.Equal(t, planner.ErrNoPlace, err)
+}
+
+func TestNetwork_ApplyPredictions(t *testing.T) {
+	n := planner.NewNetwork(NewTestCatalog())
+
+	predicted := true
+	n.ApplyPredictions(&oba.StopWithArrivalsAndDepartures{
+		StopID: "A",
+		ArrivalsAndDepartures: oba.ArrivalsAndDepartures{{
+			Predicted:              &predicted,
+			TripID:                 "t1",
+			ScheduledDepartureTime: ms(at(8, 0)),
+			PredictedDepartureTime: ms(at(8, 4)),
+		}},
+	})
+
+	its, err := n.Plan(planner.StopPlace("A"), planner.StopPlace("D"), at(7, 55), planner.Options{})
+	assert.NoError(t, err)
+	if assert.Len(t, its, 1, "Itineraries") {
+		assert.True(t, its[0].Arrival.Equal(at(8, 35)), "late t1 misses t3")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file := "../testdata/stops-for-route.json"
+		if strings.Contains(r.URL.Path, "schedule-for-route") {
+			file = "../testdata/schedule-for-route.json"
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	client := oba.NewDefaultClientS(server.URL, "key")
+	c, err := planner.Fetch(client, []string{"1_100224"}, time.Time{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NotEmpty(t, c.Stops, "Stops")
+	assert.Len(t, c.RouteSchedules, 1, "RouteSchedules")
+
+	n := planner.NewNetwork(c)
+	loc, _ := time.LoadLocation("America/Los_Angeles")
+	depart := time.Date(2018, time.September, 20, 5, 50, 0, 0, loc)
+	its, err := n.Plan(planner.StopPlace("1_29270"), planner.StopPlace("1_25765"), depart, planner.Options{})
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, its, "Itineraries") {
+		it := its[len(its)-1]
+		assert.False(t, it.Arrival.After(depart.Add(25*time.Minute)), "Arrival")
+		assert.Equal(t, "1_100224", it.Legs[0].RouteID)
+	}
+}
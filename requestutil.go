@@ -8,22 +8,34 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
 )
 
-func makeGetRequest(url string) ([]byte, error) {
-	resp, err := http.Get(url)
+func makeGetRequest(r *Request) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodGet, r.URL.String(), nil)
 	if err != nil {
-		return nil, errors.New("error making request: " + err.Error())
+		return nil, 0, errors.New("error making request: " + err.Error())
+	}
+	req.Header = r.Header
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, errors.New("error making request: " + err.Error())
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return nil, errors.New("error reading body: " + err.Error())
+		return nil, resp.StatusCode, errors.New("error reading body: " + err.Error())
 	}
-	return body, nil
+	return body, resp.StatusCode, nil
 }
 
 func handleResponse(r *Response) error {
+	if r == nil {
+		return errors.New("no response")
+	}
 	if r.Code != http.StatusOK {
 		return fmt.Errorf("code: %d %v", r.Code, r.Text)
 	}
@@ -31,50 +43,103 @@ func handleResponse(r *Response) error {
 }
 
 func handleAltResponse(r *AltResponse) error {
+	if r == nil {
+		return errors.New("no response")
+	}
 	if r.Code != http.StatusOK {
 		return fmt.Errorf("code: %d %v", r.Code, r.Text)
 	}
 	return nil
 }
 
-func unmarshalResponse(data []byte) *Response {
+func unmarshalResponse(data []byte) (*Response, error) {
 	response := &Response{}
 	err := json.Unmarshal(data, response)
-	if err != nil {
-		fmt.Println("error un-marshaling response", err)
-	}
-	return response
+	return response, err
 }
 
-func unmarshalAltResponse(data []byte) *AltResponse {
+func unmarshalAltResponse(data []byte) (*AltResponse, error) {
 	response := &AltResponse{}
 	err := json.Unmarshal(data, response)
+	return response, err
+}
+
+func (c DefaultClient) requestAndHandle(u, errmsg string) (*Response, error) {
+	res, err := c.do(u, false, errmsg)
 	if err != nil {
-		fmt.Println("error un-marshaling response", err)
+		return nil, err
 	}
-	return response
+	return res.Response, nil
 }
 
-func requestAndHandle(u, errmsg string) (*Response, error) {
-	body, err := makeGetRequest(u)
+func (c DefaultClient) requestAndHandleAlt(u, errmsg string) (*AltResponse, error) {
+	res, err := c.do(u, true, errmsg)
 	if err != nil {
-		return nil, errors.New(errmsg + err.Error())
+		return nil, err
 	}
-	response := unmarshalResponse(body)
-	if err := handleResponse(response); err != nil {
-		return nil, errors.New(errmsg + err.Error())
+	return res.AltResponse, nil
+}
+
+var requestCounter uint64
+
+// nextRequestID - a process wide unique id for a request
+func nextRequestID() string {
+	return strconv.FormatUint(atomic.AddUint64(&requestCounter, 1), 10)
+}
+
+// do - run the request through the middleware chain and check the response
+// code, reporting the request to the observer once handled
+func (c DefaultClient) do(u string, alt bool, errmsg string) (*Result, error) {
+	info := RequestInfo{Endpoint: c.endpointName(u), RequestID: nextRequestID(), URL: redactKey(u)}
+	start := time.Now()
+	res, err := c.handle(u, alt, info)
+
+	info.Latency = time.Since(start)
+	if res != nil {
+		info.StatusCode, info.Bytes, info.DecodeTime = res.StatusCode, len(res.Body), res.decodeTime
+		if res.Response != nil {
+			info.Code = res.Response.Code
+		} else if res.AltResponse != nil {
+			info.Code = res.AltResponse.Code
+		}
+	}
+	if err != nil {
+		err = errors.New(errmsg + err.Error())
+	}
+	info.Err = err
+	c.observe(info)
+
+	fields := []Field{
+		F("endpoint", info.Endpoint),
+		F("request_id", info.RequestID),
+		F("duration", info.Latency),
+		F("status", info.StatusCode),
+		F("code", info.Code),
 	}
-	return response, nil
+	if err != nil {
+		c.log().Log(LevelWarn, "request failed", append(fields, F("error", err))...)
+		return nil, err
+	}
+	c.log().Log(LevelDebug, "request", append(fields, F("url", info.URL), F("bytes", info.Bytes))...)
+	return res, nil
 }
 
-func requestAndHandleAlt(u, errmsg string) (*AltResponse, error) {
-	body, err := makeGetRequest(u)
+func (c DefaultClient) handle(u string, alt bool, info RequestInfo) (*Result, error) {
+	parsed, err := url.Parse(u)
 	if err != nil {
-		return nil, errors.New(errmsg + err.Error())
+		return nil, err
+	}
+	req := &Request{ID: info.RequestID, Endpoint: info.Endpoint, URL: parsed, Header: make(http.Header), alt: alt}
+	res, err := c.chain()(req)
+	if err != nil {
+		return res, err
+	}
+	if res == nil {
+		return nil, errors.New("no result")
 	}
-	response := unmarshalAltResponse(body)
-	if err := handleAltResponse(response); err != nil {
-		return nil, errors.New(errmsg + err.Error())
+	res.decode(req, c.log())
+	if alt {
+		return res, handleAltResponse(res.AltResponse)
 	}
-	return response, nil
+	return res, handleResponse(res.Response)
 }
@@ -0,0 +1,274 @@
+// Package stopindex - an in-process spatial index of One Bus Away stops for
+// nearest stop, radius and bounding box queries without an api call per query.
+package stopindex
+
+import (
+	"math"
+	"sort"
+
+	"github.com/Setheck/oba"
+)
+
+// DefaultCellSize - grid cell size in degrees, roughly 1.1km of latitude
+const DefaultCellSize = 0.01
+
+// metersPerDegree - meters in one degree of latitude
+const metersPerDegree = 111195.0
+
+// Result - a stop matched by a query and its distance in meters from the query
+type Result struct {
+	Stop     oba.Stop
+	Distance float64
+}
+
+// Filter - limits the stops a query returns
+type Filter func(oba.Stop) bool
+
+// ServedBy - a filter for stops served by any of routeIDs
+func ServedBy(routeIDs ...string) Filter {
+	ids := make(map[string]bool, len(routeIDs))
+	for _, id := range routeIDs {
+		ids[id] = true
+	}
+	return func(s oba.Stop) bool {
+		for _, r := range s.Routes {
+			if ids[r.ID] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type cell struct {
+	x, y int
+}
+
+// Index - stops bucketed into a grid of cells. Queries do not handle the
+// antimeridian. An Index is not safe for concurrent Add and queries.
+type Index struct {
+	cellSize float64
+	stops    []oba.Stop
+	ids      map[string]int
+	cells    map[cell][]int
+	min, max cell
+}
+
+// New - an index of stops using DefaultCellSize
+func New(stops []oba.Stop) *Index {
+	return NewWithCellSize(stops, DefaultCellSize)
+}
+
+// NewWithCellSize - an index of stops using cells of cellSize degrees
+func NewWithCellSize(stops []oba.Stop, cellSize float64) *Index {
+	if cellSize <= 0 {
+		cellSize = DefaultCellSize
+	}
+	i := &Index{
+		cellSize: cellSize,
+		ids:      make(map[string]int),
+		cells:    make(map[cell][]int),
+	}
+	i.Add(stops...)
+	return i
+}
+
+// Len - number of stops in the index
+func (i *Index) Len() int {
+	return len(i.stops)
+}
+
+// Stops - every stop in the index
+func (i *Index) Stops() []oba.Stop {
+	return i.stops
+}
+
+// Stop - a stop by id
+func (i *Index) Stop(id string) (oba.Stop, bool) {
+	n, ok := i.ids[id]
+	if !ok {
+		return oba.Stop{}, false
+	}
+	return i.stops[n], true
+}
+
+// Add - add stops to the index, replacing any stop with the same id
+func (i *Index) Add(stops ...oba.Stop) {
+	for _, s := range stops {
+		if n, ok := i.ids[s.ID]; ok {
+			i.remove(i.cellOf(i.stops[n].Lat, i.stops[n].Lon), n)
+			i.stops[n] = s
+			i.insert(n)
+			continue
+		}
+		i.ids[s.ID] = len(i.stops)
+		i.stops = append(i.stops, s)
+		i.insert(len(i.stops) - 1)
+	}
+}
+
+func (i *Index) insert(n int) {
+	c := i.cellOf(i.stops[n].Lat, i.stops[n].Lon)
+	if len(i.cells) == 0 {
+		i.min, i.max = c, c
+	}
+	i.cells[c] = append(i.cells[c], n)
+	i.min.x, i.min.y = minInt(i.min.x, c.x), minInt(i.min.y, c.y)
+	i.max.x, i.max.y = maxInt(i.max.x, c.x), maxInt(i.max.y, c.y)
+}
+
+func (i *Index) remove(c cell, n int) {
+	ns := i.cells[c]
+	for j, v := range ns {
+		if v == n {
+			i.cells[c] = append(ns[:j], ns[j+1:]...)
+			break
+		}
+	}
+}
+
+func (i *Index) cellOf(lat, lon float64) cell {
+	return cell{x: int(math.Floor(lon / i.cellSize)), y: int(math.Floor(lat / i.cellSize))}
+}
+
+// Nearest - the k stops nearest lat, lon that pass every filter, nearest first
+func (i *Index) Nearest(lat, lon float64, k int, filters ...Filter) []Result {
+	if k <= 0 || len(i.stops) == 0 {
+		return nil
+	}
+	q := oba.Location{Lat: lat, Lon: lon}
+	center := i.cellOf(lat, lon)
+	maxRing := maxInt(
+		maxInt(absInt(center.x-i.min.x), absInt(center.x-i.max.x)),
+		maxInt(absInt(center.y-i.min.y), absInt(center.y-i.max.y)))
+
+	var found []Result
+	for r := 0; r <= maxRing; r++ {
+		for _, c := range ring(center, r) {
+			for _, n := range i.cells[c] {
+				s := i.stops[n]
+				if match(s, filters) {
+					found = append(found, Result{Stop: s, Distance: q.DistanceTo(s.Location())})
+				}
+			}
+		}
+		// every stop closer than the edge of the searched square has been seen
+		if len(found) >= k {
+			sortResults(found)
+			if found[k-1].Distance <= i.searched(lat, r) {
+				break
+			}
+		}
+	}
+	sortResults(found)
+	if len(found) > k {
+		found = found[:k]
+	}
+	return found
+}
+
+// searched - meters from a query at lat to the edge of the square of rings
+// 0 through r, a lower bound for any stop outside it
+func (i *Index) searched(lat float64, r int) float64 {
+	deg := float64(r) * i.cellSize
+	poleward := math.Min(math.Abs(lat)+deg+i.cellSize, 90)
+	return deg * metersPerDegree * math.Cos(poleward*math.Pi/180)
+}
+
+// Radius - the stops within meters of lat, lon that pass every filter, nearest
+// first
+func (i *Index) Radius(lat, lon, meters float64, filters ...Filter) []Result {
+	q := oba.Location{Lat: lat, Lon: lon}
+	dLat := meters / metersPerDegree
+	dLon := 180.0
+	if c := math.Cos(math.Min(math.Abs(lat)+dLat, 90) * math.Pi / 180); c > 0 {
+		dLon = math.Min(dLat/c, 180)
+	}
+	var found []Result
+	i.scan(lat-dLat, lon-dLon, lat+dLat, lon+dLon, func(s oba.Stop) {
+		if d := q.DistanceTo(s.Location()); d <= meters && match(s, filters) {
+			found = append(found, Result{Stop: s, Distance: d})
+		}
+	})
+	sortResults(found)
+	return found
+}
+
+// BoundingBox - the stops inside the box that pass every filter, ordered by id
+func (i *Index) BoundingBox(minLat, minLon, maxLat, maxLon float64, filters ...Filter) []oba.Stop {
+	var found []oba.Stop
+	i.scan(minLat, minLon, maxLat, maxLon, func(s oba.Stop) {
+		if s.Lat >= minLat && s.Lat <= maxLat && s.Lon >= minLon && s.Lon <= maxLon && match(s, filters) {
+			found = append(found, s)
+		}
+	})
+	sort.Slice(found, func(a, b int) bool {
+		return found[a].ID < found[b].ID
+	})
+	return found
+}
+
+// scan - call fn for the stops of every cell overlapping the box
+func (i *Index) scan(minLat, minLon, maxLat, maxLon float64, fn func(oba.Stop)) {
+	lo, hi := i.cellOf(minLat, minLon), i.cellOf(maxLat, maxLon)
+	lo.x, lo.y = maxInt(lo.x, i.min.x), maxInt(lo.y, i.min.y)
+	hi.x, hi.y = minInt(hi.x, i.max.x), minInt(hi.y, i.max.y)
+	for y := lo.y; y <= hi.y; y++ {
+		for x := lo.x; x <= hi.x; x++ {
+			for _, n := range i.cells[cell{x: x, y: y}] {
+				fn(i.stops[n])
+			}
+		}
+	}
+}
+
+// ring - the cells at chebyshev distance r from c
+func ring(c cell, r int) []cell {
+	if r == 0 {
+		return []cell{c}
+	}
+	cells := make([]cell, 0, 8*r)
+	for d := -r; d <= r; d++ {
+		cells = append(cells, cell{x: c.x + d, y: c.y - r}, cell{x: c.x + d, y: c.y + r})
+	}
+	for d := -r + 1; d <= r-1; d++ {
+		cells = append(cells, cell{x: c.x - r, y: c.y + d}, cell{x: c.x + r, y: c.y + d})
+	}
+	return cells
+}
+
+func match(s oba.Stop, filters []Filter) bool {
+	for _, f := range filters {
+		if !f(s) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortResults(rs []Result) {
+	sort.SliceStable(rs, func(a, b int) bool {
+		return rs[a].Distance < rs[b].Distance
+	})
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func absInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
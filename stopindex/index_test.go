@@ -0,0 +1,173 @@
+package stopindex_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/Setheck/oba/stopindex"
+	"github.com/stretchr/testify/assert"
+)
+
+// NewTestStops - n stops scattered around Seattle, even stops served by route
+// "even" and odd stops by "odd"
+func NewTestStops(n int) []oba.Stop {
+	r := rand.New(rand.NewSource(1))
+	stops := make([]oba.Stop, 0, n)
+	for i := 0; i < n; i++ {
+		route := "odd"
+		if i%2 == 0 {
+			route = "even"
+		}
+		stops = append(stops, oba.Stop{
+			ID:     fmt.Sprint("1_", i),
+			Lat:    47.5 + r.Float64()*0.3,
+			Lon:    -122.45 + r.Float64()*0.3,
+			Routes: []oba.Route{{ID: route}},
+		})
+	}
+	return stops
+}
+
+func bruteForce(stops []oba.Stop, lat, lon float64, keep func(oba.Stop) bool) []stopindex.Result {
+	q := oba.Location{Lat: lat, Lon: lon}
+	var rs []stopindex.Result
+	for _, s := range stops {
+		if keep == nil || keep(s) {
+			rs = append(rs, stopindex.Result{Stop: s, Distance: q.DistanceTo(s.Location())})
+		}
+	}
+	sort.SliceStable(rs, func(a, b int) bool { return rs[a].Distance < rs[b].Distance })
+	return rs
+}
+
+func ids(rs []stopindex.Result) []string {
+	out := make([]string, 0, len(rs))
+	for _, r := range rs {
+		out = append(out, r.Stop.ID)
+	}
+	return out
+}
+
+func TestIndex_Nearest(t *testing.T) {
+	stops := NewTestStops(2000)
+	idx := stopindex.New(stops)
+	assert.Equal(t, 2000, idx.Len())
+
+	for _, q := range [][2]float64{{47.6, -122.3}, {47.51, -122.44}, {48.5, -121.0}} {
+		want := bruteForce(stops, q[0], q[1], nil)[:7]
+		got := idx.Nearest(q[0], q[1], 7)
+		assert.Equal(t, ids(want), ids(got), "Nearest %v", q)
+	}
+
+	even := stopindex.ServedBy("even")
+	want := bruteForce(stops, 47.6, -122.3, even)[:5]
+	got := idx.Nearest(47.6, -122.3, 5, even)
+	assert.Equal(t, ids(want), ids(got), "Nearest - ServedBy")
+
+	assert.Empty(t, idx.Nearest(47.6, -122.3, 0))
+	assert.Len(t, stopindex.New(stops[:3]).Nearest(47.6, -122.3, 10), 3, "fewer than k")
+}
+
+func TestIndex_Radius(t *testing.T) {
+	stops := NewTestStops(2000)
+	idx := stopindex.New(stops)
+
+	var want []stopindex.Result
+	for _, r := range bruteForce(stops, 47.6, -122.3, nil) {
+		if r.Distance <= 1500 {
+			want = append(want, r)
+		}
+	}
+	got := idx.Radius(47.6, -122.3, 1500)
+	assert.NotEmpty(t, got)
+	assert.Equal(t, ids(want), ids(got), "Radius")
+	for _, r := range idx.Radius(47.6, -122.3, 1500, stopindex.ServedBy("odd")) {
+		assert.Equal(t, "odd", r.Stop.Routes[0].ID)
+	}
+}
+
+func TestIndex_BoundingBox(t *testing.T) {
+	stops := NewTestStops(500)
+	idx := stopindex.New(stops)
+
+	var want []string
+	for _, s := range stops {
+		if s.Lat >= 47.55 && s.Lat <= 47.65 && s.Lon >= -122.4 && s.Lon <= -122.3 {
+			want = append(want, s.ID)
+		}
+	}
+	sort.Strings(want)
+	got := idx.BoundingBox(47.55, -122.4, 47.65, -122.3)
+	gotIDs := make([]string, 0, len(got))
+	for _, s := range got {
+		gotIDs = append(gotIDs, s.ID)
+	}
+	assert.Equal(t, want, gotIDs, "BoundingBox")
+}
+
+func TestIndex_Add(t *testing.T) {
+	idx := stopindex.New([]oba.Stop{{ID: "a", Lat: 47.6, Lon: -122.3}})
+	idx.Add(oba.Stop{ID: "a", Lat: 47.7, Lon: -122.3, Name: "moved"})
+	assert.Equal(t, 1, idx.Len())
+	assert.Empty(t, idx.Radius(47.6, -122.3, 100), "old position")
+	if rs := idx.Radius(47.7, -122.3, 100); assert.Len(t, rs, 1) {
+		assert.Equal(t, "moved", rs[0].Stop.Name)
+	}
+}
+
+func TestIndex_SaveLoad(t *testing.T) {
+	stops := NewTestStops(100)
+	idx := stopindex.NewWithCellSize(stops, 0.05)
+
+	var buf bytes.Buffer
+	assert.NoError(t, idx.Save(&buf))
+	loaded, err := stopindex.Load(&buf)
+	if assert.NoError(t, err) {
+		assert.Equal(t, idx.Stops(), loaded.Stops())
+		assert.Equal(t, ids(idx.Nearest(47.6, -122.3, 5)), ids(loaded.Nearest(47.6, -122.3, 5)))
+	}
+
+	dir, err := ioutil.TempDir("", "stopindex")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stops.idx")
+	assert.NoError(t, idx.SaveFile(path))
+	loaded, err = stopindex.LoadFile(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 100, loaded.Len())
+	}
+}
+
+func TestFetchAgency(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file := "../testdata/stop.json"
+		if strings.Contains(r.URL.Path, "stop-ids-for-agency") {
+			file = "../testdata/stop-ids-for-agency.json"
+		}
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write(b)
+	}))
+	defer server.Close()
+
+	idx, err := stopindex.FetchAgency(oba.NewDefaultClientS(server.URL, "key"), "1")
+	assert.NoError(t, err)
+	// every id serves the same fixture stop
+	assert.Equal(t, 1, idx.Len())
+	_, ok := idx.Stop("1_75403")
+	assert.True(t, ok)
+}
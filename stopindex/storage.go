@@ -0,0 +1,85 @@
+package stopindex
+
+import (
+	"encoding/gob"
+	"io"
+	"os"
+
+	"github.com/Setheck/oba"
+)
+
+// snapshot - the serialized form of an Index, the grid is rebuilt on load
+type snapshot struct {
+	CellSize float64
+	Stops    []oba.Stop
+}
+
+// Save - write the index to w
+func (i *Index) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(snapshot{CellSize: i.cellSize, Stops: i.stops})
+}
+
+// Load - read an index written by Save
+func Load(r io.Reader) (*Index, error) {
+	var s snapshot
+	if err := gob.NewDecoder(r).Decode(&s); err != nil {
+		return nil, err
+	}
+	return NewWithCellSize(s.Stops, s.CellSize), nil
+}
+
+// SaveFile - write the index to path, replacing it only once fully written
+func (i *Index) SaveFile(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := i.Save(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadFile - read an index written by SaveFile
+func LoadFile(path string) (*Index, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// FetchAgency - an index of every stop of an agency, from StopIDsForAgency and
+// the batch Stops. Stops that failed to fetch are left out and reported in the
+// returned oba.BatchError alongside the index.
+func FetchAgency(client oba.Client, agencyID string) (*Index, error) {
+	ids, err := client.StopIDsForAgency(agencyID)
+	if err != nil {
+		return nil, err
+	}
+	stops, err := client.Stops(ids)
+	return New(stops), err
+}
+
+// FetchRoutes - an index of the stops served by routeIDs, from StopsForRoute
+func FetchRoutes(client oba.Client, routeIDs []string) (*Index, error) {
+	i := New(nil)
+	for _, id := range routeIDs {
+		sfr, err := client.StopsForRoute(id)
+		if err != nil {
+			return nil, err
+		}
+		if sfr != nil {
+			i.Add(sfr.Stops...)
+		}
+	}
+	return i, nil
+}
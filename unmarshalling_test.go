@@ -3,7 +3,11 @@
 package oba_test
 
 import (
+	"encoding/json"
 	"testing"
+
+	"github.com/Setheck/oba"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestAgenciesWithCoverage(t *testing.T) {
@@ -71,11 +75,26 @@ func TestRoutesForLocation(t *testing.T) {
 	VerifyUnMarshalling(t, contents)
 }
 
+func TestScheduleForRoute(t *testing.T) {
+	contents := RetrieveTestJsonFileContent(t)
+	VerifyUnMarshalling(t, contents)
+}
+
 func TestScheduleForStop(t *testing.T) {
 	contents := RetrieveTestJsonFileContent(t)
 	VerifyUnMarshalling(t, contents)
 }
 
+func TestSearchRoute(t *testing.T) {
+	contents := RetrieveTestJsonFileContent(t)
+	VerifyUnMarshalling(t, contents)
+}
+
+func TestSearchStop(t *testing.T) {
+	contents := RetrieveTestJsonFileContent(t)
+	VerifyUnMarshalling(t, contents)
+}
+
 func TestShape(t *testing.T) {
 	contents := RetrieveTestJsonFileContent(t)
 	VerifyUnMarshalling(t, contents)
@@ -130,3 +149,39 @@ func TestVehiclesForAgency(t *testing.T) {
 	contents := RetrieveTestJsonFileContent(t)
 	VerifyUnMarshalling(t, contents)
 }
+
+func TestEntryStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		json      string
+		status    string
+		vehicleID string
+		stopTimes int
+	}{
+		{"text", `{"status":"default","schedule":"testvalue"}`, "default", "", -1},
+		{"null", `{"status":null}`, "", "", -1},
+		{"elements", `{"status":{"vehicleId":"1_4361","status":"default"},` +
+			`"schedule":{"stopTimes":[{"stopId":"1_1"},{"stopId":"1_2"}]}}`, "", "1_4361", 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var e oba.Entry
+			if !assert.NoError(t, json.Unmarshal([]byte(test.json), &e)) {
+				return
+			}
+			assert.Equal(t, test.status, e.Status)
+			td := e.ToTripDetails(nil, nil)
+			if test.vehicleID == "" {
+				assert.Nil(t, td.TripStatus)
+			} else if assert.NotNil(t, td.TripStatus) {
+				assert.Equal(t, test.vehicleID, td.TripStatus.VehicleID)
+				assert.Equal(t, "default", td.TripStatus.Status)
+			}
+			if test.stopTimes < 0 {
+				assert.Nil(t, td.Schedule)
+			} else if assert.NotNil(t, td.Schedule) {
+				assert.Len(t, td.Schedule.StopTimes, test.stopTimes)
+			}
+		})
+	}
+}
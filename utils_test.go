@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"regexp"
 	"strings"
 	"testing"
@@ -29,6 +30,19 @@ func FakeServer(t *testing.T, body []byte) *httptest.Server {
 	return httptest.NewServer(handler)
 }
 
+// QueryServer - like FakeServer, but records the query of the last request
+func QueryServer(t *testing.T, body []byte, query *url.Values) *httptest.Server {
+	t.Helper()
+	handler := http.HandlerFunc(func(r http.ResponseWriter, req *http.Request) {
+		*query = req.URL.Query()
+		_, err := r.Write(body)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+	return httptest.NewServer(handler)
+}
+
 func RetrieveTestJsonFileContent(t *testing.T) []byte {
 	t.Helper()
 	file := ConvertToFilename(t.Name())
@@ -197,6 +211,10 @@ func VerifyStopSchedule(t *testing.T, s *oba.StopSchedule) {
 	t.Helper()
 	assert.NotNil(t, s, "StopSchedule")
 	assert.NotEmpty(t, s.Date, "StopSchedule - Date")
+	assert.NotEmpty(t, s.TimeZone, "StopSchedule - TimeZone")
+	assert.NotEmpty(t, s.StopCalendarDays, "StopSchedule - StopCalendarDays")
+	assert.NotEmpty(t, s.StopRouteSchedules, "StopSchedule - StopRouteSchedules")
+	VerifyStop(t, &s.Stop)
 	for _, scd := range s.StopCalendarDays {
 		VerifyStopCalendarDay(t, &scd)
 	}
@@ -229,6 +247,74 @@ func VerifyStopRouteDirectionSchedule(t *testing.T, s *oba.StopRouteDirectionSch
 	for _, sst := range s.ScheduleStopTimes {
 		VerifyScheduleStopTime(t, &sst)
 	}
+	for _, sf := range s.ScheduleFrequencies {
+		VerifyScheduleFrequency(t, &sf)
+	}
+}
+
+func VerifyFrequency(t *testing.T, f *oba.Frequency) {
+	t.Helper()
+	assert.NotNil(t, f, "Frequency")
+	if f == nil {
+		return
+	}
+	assert.NotZero(t, f.StartTime, "Frequency - StartTime")
+	assert.NotZero(t, f.EndTime, "Frequency - EndTime")
+	assert.NotZero(t, f.Headway, "Frequency - Headway")
+	assert.True(t, f.EndTime > f.StartTime, "Frequency - EndTime after StartTime")
+}
+
+func VerifyScheduleFrequency(t *testing.T, s *oba.ScheduleFrequency) {
+	t.Helper()
+	assert.NotNil(t, s, "ScheduleFrequency")
+	VerifyFrequency(t, &s.Frequency)
+	assert.NotNil(t, s.ArrivalEnabled, "ScheduleFrequency - ArrivalEnabled")
+	assert.NotNil(t, s.DepartureEnabled, "ScheduleFrequency - DepartureEnabled")
+	assert.NotZero(t, s.ServiceDate, "ScheduleFrequency - ServiceDate")
+	assert.NotEmpty(t, s.ServiceID, "ScheduleFrequency - ServiceID")
+	assert.NotEmpty(t, s.TripID, "ScheduleFrequency - TripID")
+}
+
+func VerifyRouteSchedule(t *testing.T, r *oba.RouteSchedule) {
+	t.Helper()
+	assert.NotNil(t, r, "RouteSchedule")
+	assert.NotZero(t, r.ScheduleDate, "RouteSchedule - ScheduleDate")
+	assert.NotEmpty(t, r.ServiceIDs, "RouteSchedule - ServiceIDs")
+	assert.NotEmpty(t, r.StopTripGroupings, "RouteSchedule - StopTripGroupings")
+	VerifyRoute(t, &r.Route)
+	for _, stg := range r.StopTripGroupings {
+		VerifyStopTripGrouping(t, &stg)
+	}
+}
+
+func VerifyStopTripGrouping(t *testing.T, s *oba.StopTripGrouping) {
+	t.Helper()
+	assert.NotNil(t, s, "StopTripGrouping")
+	assert.NotEmpty(t, s.DirectionID, "StopTripGrouping - DirectionID")
+	assert.NotEmpty(t, s.TripHeadsigns, "StopTripGrouping - TripHeadsigns")
+	assert.NotEmpty(t, s.Stops, "StopTripGrouping - Stops")
+	assert.NotEmpty(t, s.Trips, "StopTripGrouping - Trips")
+	assert.NotEmpty(t, s.TripsWithStopTimes, "StopTripGrouping - TripsWithStopTimes")
+	for _, stop := range s.Stops {
+		VerifyStop(t, &stop)
+	}
+	for _, tr := range s.Trips {
+		VerifyTrip(t, &tr)
+	}
+	for _, tws := range s.TripsWithStopTimes {
+		VerifyTripWithStopTimes(t, &tws)
+	}
+}
+
+func VerifyTripWithStopTimes(t *testing.T, tws *oba.TripWithStopTimes) {
+	t.Helper()
+	assert.NotNil(t, tws, "TripWithStopTimes")
+	assert.NotEmpty(t, tws.StopTimes, "TripWithStopTimes - StopTimes")
+	VerifyTrip(t, &tws.Trip)
+	for _, st := range tws.StopTimes {
+		VerifyScheduleStopTime(t, &st)
+		assert.NotEmpty(t, st.StopID, "ScheduleStopTime - StopID")
+	}
 }
 
 func VerifyShape(t *testing.T, s *oba.Shape) {
@@ -269,7 +355,7 @@ func VerifyScheduleStopTime(t *testing.T, s *oba.ScheduleStopTime) {
 func VerifyTripDetails(t *testing.T, td *oba.TripDetails) {
 	t.Helper()
 	assert.NotNil(t, td, "TripDetails")
-	assert.NotEmpty(t, td.Frequency, "TripDetails - Frequency")
+	VerifyFrequency(t, td.Frequency)
 	assert.NotZero(t, td.ServiceDate, "TripDetails - ServiceDate")
 	assert.NotEmpty(t, td.Status, "TripDetails - Status")
 	VerifyTrip(t, &td.Trip)
@@ -328,7 +414,7 @@ func VerifyTripStatus(t *testing.T, ts *oba.TripStatus) {
 	VerifyStop(t, &ts.ClosestStop)
 	assert.NotZero(t, ts.ClosestStopTimeOffset, "TripStatus - ClosestStopTimeOffset")
 	assert.NotZero(t, ts.DistanceAlongTrip, "TripStatus - DistanceAlongTrip")
-	assert.NotEmpty(t, ts.Frequency, "TripStatus - Frequency")
+	VerifyFrequency(t, ts.Frequency)
 	assert.NotZero(t, ts.LastKnownDistanceAlongTrip, "TripStatus - LastKnownDistanceAlongTrip")
 	assert.NotZero(t, ts.LastKnownOrientation, "TripStatus - LastKnownOrientation")
 	VerifyLocation(t, &ts.LastKnownLocation)